@@ -1,6 +1,27 @@
 package binding
 
-import "net/http"
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// 请求中常用的Content-Type
+const (
+	MIMEJSON              = "application/json"
+	MIMEHTML              = "text/html"
+	MIMEXML               = "application/xml"
+	MIMEXML2              = "text/xml"
+	MIMEPlain             = "text/plain"
+	MIMEPOSTForm          = "application/x-www-form-urlencoded"
+	MIMEMultipartPOSTForm = "multipart/form-data"
+	MIMEPROTOBUF          = "application/x-protobuf"
+	MIMEYAML              = "application/x-yaml"
+	MIMEYAML2             = "application/yaml"
+	MIMETOML              = "application/toml"
+	MIMEMSGPACK           = "application/x-msgpack"
+	MIMEMSGPACK2          = "application/msgpack"
+)
 
 type Binding interface {
 	Name() string
@@ -9,3 +30,74 @@ type Binding interface {
 
 var JSON = jsonBinding{}
 var XML = xmlBinding{}
+var Form = formBinding{}
+var Query = queryBinding{}
+var FormMultipart = multipartBinding{}
+var Header = headerBinding{}
+var Uri = uriBinding{}
+var YAML = yamlBinding{}
+var TOML = tomlBinding{}
+var ProtoBuf = protobufBinding{}
+var MsgPack = msgpackBinding{}
+
+// Content-Type和绑定器的对应关系
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Binding{
+		MIMEJSON:              JSON,
+		MIMEXML:               XML,
+		MIMEXML2:              XML,
+		MIMEPOSTForm:          Form,
+		MIMEMultipartPOSTForm: FormMultipart,
+		MIMEPROTOBUF:          ProtoBuf,
+		MIMEYAML:              YAML,
+		MIMEYAML2:             YAML,
+		MIMETOML:              TOML,
+		MIMEMSGPACK:           MsgPack,
+		MIMEMSGPACK2:          MsgPack,
+	}
+)
+
+// Register 注册Content-Type对应的绑定器，已经存在时替换原来的绑定器
+// 比如 binding.Register("application/vnd.api+json", binding.JSON)
+func Register(contentType string, b Binding) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[mediaType(contentType)] = b
+}
+
+// UnsupportedMediaTypeError 没有Content-Type对应的绑定器，对应的状态码为415
+type UnsupportedMediaTypeError struct {
+	ContentType string
+}
+
+func (e *UnsupportedMediaTypeError) Error() string {
+	return "unsupported media type: " + e.ContentType
+}
+
+// Default 根据请求方法和Content-Type选择绑定器
+// GET、HEAD请求以及没有Content-Type的请求绑定查询参数和表单参数
+func Default(method, contentType string) (Binding, error) {
+	if method == http.MethodGet || method == http.MethodHead {
+		return Form, nil
+	}
+	t := mediaType(contentType)
+	if t == "" {
+		return Form, nil
+	}
+	registryMu.RLock()
+	b, ok := registry[t]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, &UnsupportedMediaTypeError{ContentType: contentType}
+	}
+	return b, nil
+}
+
+// 去掉Content-Type中的参数，比如 application/json; charset=utf-8 返回 application/json
+func mediaType(contentType string) string {
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
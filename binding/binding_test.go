@@ -0,0 +1,116 @@
+package binding
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type csvBinding struct{}
+
+func (csvBinding) Name() string {
+	return "csv"
+}
+
+func (csvBinding) Bind(r *http.Request, obj any) error {
+	return nil
+}
+
+func TestDefault(t *testing.T) {
+	Register("text/csv", csvBinding{})
+	tests := []struct {
+		method      string
+		contentType string
+		want        Binding
+	}{
+		{http.MethodGet, MIMEJSON, Form},
+		{http.MethodPost, "", Form},
+		{http.MethodPost, "application/json; charset=utf-8", JSON},
+		{http.MethodPut, MIMEXML2, XML},
+		{http.MethodPost, MIMEPOSTForm, Form},
+		{http.MethodPost, "multipart/form-data; boundary=xyz", FormMultipart},
+		{http.MethodPost, MIMEYAML, YAML},
+		{http.MethodPatch, MIMETOML, TOML},
+		{http.MethodPost, MIMEPROTOBUF, ProtoBuf},
+		{http.MethodPost, MIMEMSGPACK2, MsgPack},
+		{http.MethodPost, "Text/CSV", csvBinding{}},
+	}
+	for _, tt := range tests {
+		b, err := Default(tt.method, tt.contentType)
+		if err != nil || b != tt.want {
+			t.Errorf("%s %s: got %v %v, want %v", tt.method, tt.contentType, b, err, tt.want)
+		}
+	}
+
+	_, err := Default(http.MethodPost, "image/png")
+	var unsupported *UnsupportedMediaTypeError
+	if !errors.As(err, &unsupported) || unsupported.ContentType != "image/png" {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestYAMLAndTOMLBinding(t *testing.T) {
+	type user struct {
+		Name string   `yaml:"name" toml:"name" validate:"required"`
+		Tags []string `yaml:"tags" toml:"tags"`
+	}
+	var u user
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name: cs\ntags: [a, b]\n"))
+	if err := YAML.Bind(r, &u); err != nil || u.Name != "cs" || len(u.Tags) != 2 {
+		t.Errorf("yaml: got %+v %v", u, err)
+	}
+	u = user{}
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name = \"cs\"\ntags = [\"a\"]\n"))
+	if err := TOML.Bind(r, &u); err != nil || u.Name != "cs" || len(u.Tags) != 1 {
+		t.Errorf("toml: got %+v %v", u, err)
+	}
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("tags = [\"a\"]\n"))
+	if err := TOML.Bind(r, &user{}); err == nil {
+		t.Error("toml: expected validation error")
+	}
+}
+
+func TestMsgPackBinding(t *testing.T) {
+	type user struct {
+		Name string `json:"name" validate:"required"`
+		Age  int    `msgpack:"years"`
+	}
+	data, err := msgpack.Marshal(map[string]any{"name": "cs", "years": 18})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var u user
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+	if err := MsgPack.Bind(r, &u); err != nil || u.Name != "cs" || u.Age != 18 {
+		t.Errorf("got %+v %v", u, err)
+	}
+	data, _ = msgpack.Marshal(map[string]any{"years": 18})
+	r = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+	var ve *ValidationError
+	if err := MsgPack.Bind(r, &user{}); !errors.As(err, &ve) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestProtoBufBinding(t *testing.T) {
+	data, err := proto.Marshal(wrapperspb.String("cs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg wrapperspb.StringValue
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+	if err := ProtoBuf.Bind(r, &msg); err != nil || msg.GetValue() != "cs" {
+		t.Errorf("got %q %v", msg.GetValue(), err)
+	}
+	r = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+	if err := ProtoBuf.Bind(r, &struct{}{}); err == nil {
+		t.Error("expected error for non proto message")
+	}
+}
@@ -0,0 +1,96 @@
+package binding
+
+import (
+	"errors"
+	"net/http"
+)
+
+// 解析multipart表单时默认最多使用的内存
+const defaultMemory = 32 << 20
+
+type formBinding struct{}
+
+func (formBinding) Name() string {
+	return "form"
+}
+
+// Bind 绑定查询参数和表单参数，标签为form
+func (formBinding) Bind(r *http.Request, obj any) error {
+	if err := r.ParseMultipartForm(defaultMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return err
+	}
+	if err := mapForm(obj, r.Form, "form"); err != nil {
+		return err
+	}
+	return validate(r, obj)
+}
+
+type queryBinding struct{}
+
+func (queryBinding) Name() string {
+	return "query"
+}
+
+// Bind 只绑定查询参数，标签为query
+func (queryBinding) Bind(r *http.Request, obj any) error {
+	if err := mapForm(obj, r.URL.Query(), "query"); err != nil {
+		return err
+	}
+	return validate(r, obj)
+}
+
+type multipartBinding struct{}
+
+func (multipartBinding) Name() string {
+	return "multipart/form-data"
+}
+
+// Bind 绑定multipart表单中的参数和文件，文件字段的类型为 *multipart.FileHeader 或者 []*multipart.FileHeader
+func (multipartBinding) Bind(r *http.Request, obj any) error {
+	if err := r.ParseMultipartForm(defaultMemory); err != nil {
+		return err
+	}
+	if err := mapFormWithFiles(obj, r.MultipartForm.Value, r.MultipartForm.File, "form"); err != nil {
+		return err
+	}
+	return validate(r, obj)
+}
+
+type headerBinding struct{}
+
+func (headerBinding) Name() string {
+	return "header"
+}
+
+// Bind 绑定请求头，标签为header，请求头的名字不区分大小写
+func (headerBinding) Bind(r *http.Request, obj any) error {
+	if err := mapHeader(obj, r.Header); err != nil {
+		return err
+	}
+	return validate(r, obj)
+}
+
+// BindingUri 绑定路由中的路径参数，路径参数不在http.Request中，所以单独定义
+type BindingUri interface {
+	Name() string
+	BindUri(map[string][]string, any) error
+}
+
+type uriBinding struct{}
+
+func (uriBinding) Name() string {
+	return "uri"
+}
+
+// BindUri 绑定路径参数，标签为uri，比如路由 /user/:id 对应 `uri:"id"`
+func (b uriBinding) BindUri(params map[string][]string, obj any) error {
+	return b.BindUriWith(params, obj, Validator)
+}
+
+// BindUriWith 绑定路径参数，使用指定的验证器校验
+func (uriBinding) BindUriWith(params map[string][]string, obj any, v StructValidator) error {
+	if err := mapForm(obj, params, "uri"); err != nil {
+		return err
+	}
+	return validateWith(v, obj)
+}
@@ -0,0 +1,351 @@
+package binding
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+	unmarshalType  = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// 把键值对按照结构体的标签绑定到obj，比如
+//
+//	type Search struct {
+//		Keyword string            `form:"keyword"`
+//		Page    int               `form:"page,default=1"`
+//		Tags    []string          `form:"tags"`
+//		User    map[string]string `form:"user"` //user[name]=cs&user[age]=18
+//		Since   *time.Time        `form:"since" time_format:"2006-01-02"`
+//	}
+//
+// 标签为 - 的字段跳过，没有标签时使用字段名；没有标签的结构体字段展开后继续绑定
+func mapForm(obj any, values map[string][]string, tag string) error {
+	m := &formMapper{values: values, tag: tag}
+	return m.mapTo(obj)
+}
+
+func mapFormWithFiles(obj any, values map[string][]string, files map[string][]*multipart.FileHeader, tag string) error {
+	m := &formMapper{values: values, files: files, tag: tag}
+	return m.mapTo(obj)
+}
+
+// 请求头的名字不区分大小写，标签中的名字转换成规范的格式后再查找
+func mapHeader(obj any, header http.Header) error {
+	m := &formMapper{values: header, tag: "header", canonical: true}
+	return m.mapTo(obj)
+}
+
+type formMapper struct {
+	values map[string][]string
+	files  map[string][]*multipart.FileHeader
+	tag    string
+	//名字是否转换成请求头的规范格式
+	canonical bool
+}
+
+func (m *formMapper) mapTo(obj any) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return errors.New("no ptr kind")
+	}
+	v = v.Elem()
+	if v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String {
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for key, vs := range m.values {
+			if err := setMapValue(v, key, vs, reflect.StructField{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if v.Kind() != reflect.Struct {
+		return errors.New("binding element must be a struct or map")
+	}
+	return m.mapStruct(v)
+}
+
+func (m *formMapper) mapStruct(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		if err := m.mapField(v.Field(i), field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *formMapper) mapField(value reflect.Value, field reflect.StructField) error {
+	tagValue, hasTag := field.Tag.Lookup(m.tag)
+	name, opts, _ := strings.Cut(tagValue, ",")
+	if name == "-" {
+		return nil
+	}
+	//没有标签的结构体展开绑定，time.Time等当作普通的值
+	if !hasTag && isNestedStruct(field.Type) {
+		if value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				//嵌入的未导出结构体指针不能赋值，和encoding/json一样跳过
+				if !value.CanSet() {
+					return nil
+				}
+				value.Set(reflect.New(field.Type.Elem()))
+			}
+			value = value.Elem()
+		}
+		return m.mapStruct(value)
+	}
+	//嵌入的未导出类型不能赋值
+	if !value.CanSet() {
+		return nil
+	}
+	if name == "" {
+		name = field.Name
+	}
+	if m.canonical {
+		name = textproto.CanonicalMIMEHeaderKey(name)
+	}
+	if m.files != nil && isFileField(field.Type) {
+		return setFiles(value, m.files[name])
+	}
+	if value.Kind() == reflect.Map {
+		return m.mapNested(value, name, field)
+	}
+	vs, ok := m.values[name]
+	if !ok {
+		//兼容 tags[]=a&tags[]=b 的写法
+		vs, ok = m.values[name+"[]"]
+	}
+	if !ok || len(vs) == 0 {
+		defaultValue, ok := defaultOption(opts)
+		if !ok {
+			return nil
+		}
+		vs = []string{defaultValue}
+		if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+			vs = strings.Split(defaultValue, ";")
+		}
+	}
+	if err := setValue(value, vs, field); err != nil {
+		return fmt.Errorf("binding field [%s]: %w", name, err)
+	}
+	return nil
+}
+
+// 绑定 user[name]=cs 这样的键，和 Context.get 的解析方式一致
+func (m *formMapper) mapNested(value reflect.Value, name string, field reflect.StructField) error {
+	if value.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("binding field [%s]: map key must be string", name)
+	}
+	for key, vs := range m.values {
+		if i := strings.IndexByte(key, '['); i >= 1 && key[:i] == name {
+			if j := strings.IndexByte(key[i+1:], ']'); j >= 1 {
+				if value.IsNil() {
+					value.Set(reflect.MakeMap(value.Type()))
+				}
+				if err := setMapValue(value, key[i+1:][:j], vs, field); err != nil {
+					return fmt.Errorf("binding field [%s]: %w", key, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func setMapValue(m reflect.Value, key string, vs []string, field reflect.StructField) error {
+	elem := reflect.New(m.Type().Elem()).Elem()
+	if err := setValue(elem, vs, field); err != nil {
+		return err
+	}
+	m.SetMapIndex(reflect.ValueOf(key).Convert(m.Type().Key()), elem)
+	return nil
+}
+
+func defaultOption(opts string) (string, bool) {
+	for _, opt := range strings.Split(opts, ",") {
+		if strings.HasPrefix(opt, "default=") {
+			return strings.TrimPrefix(opt, "default="), true
+		}
+	}
+	return "", false
+}
+
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType && !reflect.PointerTo(t).Implements(unmarshalType)
+}
+
+func isFileField(t reflect.Type) bool {
+	return t == fileHeaderType || (t.Kind() == reflect.Slice && t.Elem() == fileHeaderType)
+}
+
+func setFiles(value reflect.Value, files []*multipart.FileHeader) error {
+	if len(files) == 0 {
+		return nil
+	}
+	if value.Kind() == reflect.Slice {
+		value.Set(reflect.ValueOf(files))
+		return nil
+	}
+	value.Set(reflect.ValueOf(files[0]))
+	return nil
+}
+
+// 把字符串转换成字段的类型，切片和数组使用全部的值，其他类型使用第一个值
+func setValue(value reflect.Value, vs []string, field reflect.StructField) error {
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		return setValue(value.Elem(), vs, field)
+	case reflect.Slice:
+		slice := reflect.MakeSlice(value.Type(), len(vs), len(vs))
+		for i, s := range vs {
+			if err := setValue(slice.Index(i), []string{s}, field); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+		return nil
+	case reflect.Array:
+		if len(vs) != value.Len() {
+			return fmt.Errorf("%q is not valid value for %s", vs, value.Type())
+		}
+		for i, s := range vs {
+			if err := setValue(value.Index(i), []string{s}, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return setString(value, vs[0], field)
+}
+
+func setString(value reflect.Value, s string, field reflect.StructField) error {
+	if value.Type() == timeType {
+		return setTime(value, s, field)
+	}
+	if value.CanAddr() && value.Addr().Type().Implements(unmarshalType) {
+		return value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		if s == "" {
+			s = "false"
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value.Type() == durationType {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return err
+			}
+			value.SetInt(int64(d))
+			return nil
+		}
+		if s == "" {
+			s = "0"
+		}
+		n, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			s = "0"
+		}
+		n, err := strconv.ParseUint(s, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			s = "0"
+		}
+		f, err := strconv.ParseFloat(s, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	case reflect.Interface:
+		value.Set(reflect.ValueOf(s))
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+// 时间的格式由 time_format 标签指定，默认为RFC3339，unix、unixmilli、unixnano表示时间戳
+// time_location 指定时区，比如 Asia/Shanghai，time_utc:"1" 表示使用UTC
+func setTime(value reflect.Value, s string, field reflect.StructField) error {
+	if s == "" {
+		value.Set(reflect.ValueOf(time.Time{}))
+		return nil
+	}
+	layout := field.Tag.Get("time_format")
+	switch layout {
+	case "unix", "unixmilli", "unixnano":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		var t time.Time
+		switch layout {
+		case "unix":
+			t = time.Unix(n, 0)
+		case "unixmilli":
+			t = time.UnixMilli(n)
+		default:
+			t = time.Unix(0, n)
+		}
+		value.Set(reflect.ValueOf(t))
+		return nil
+	case "":
+		layout = time.RFC3339
+	}
+	loc := time.Local
+	if utc, _ := strconv.ParseBool(field.Tag.Get("time_utc")); utc {
+		loc = time.UTC
+	}
+	if name := field.Tag.Get("time_location"); name != "" {
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return err
+		}
+		loc = l
+	}
+	t, err := time.ParseInLocation(layout, s, loc)
+	if err != nil {
+		return err
+	}
+	value.Set(reflect.ValueOf(t))
+	return nil
+}
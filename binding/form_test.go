@@ -0,0 +1,181 @@
+package binding
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type address struct {
+	City string `form:"city"`
+}
+
+type searchForm struct {
+	Keyword string            `form:"keyword"`
+	Page    int               `form:"page,default=1"`
+	Size    *uint8            `form:"size"`
+	Tags    []string          `form:"tags"`
+	Ids     [2]int64          `form:"ids"`
+	User    map[string]string `form:"user"`
+	Scores  map[string]int    `form:"score"`
+	Since   time.Time         `form:"since" time_format:"2006-01-02" time_utc:"1"`
+	Until   *time.Time        `form:"until" time_format:"unix"`
+	Timeout time.Duration     `form:"timeout"`
+	Ignored string            `form:"-"`
+	address
+	Home *address
+}
+
+func TestFormBinding(t *testing.T) {
+	values := url.Values{
+		"keyword":    {"go"},
+		"size":       {"20"},
+		"tags[]":     {"web", "orm"},
+		"ids":        {"1", "2"},
+		"user[name]": {"cs"},
+		"user[age]":  {"18"},
+		"score[go]":  {"90"},
+		"since":      {"2023-01-02"},
+		"until":      {"1672617600"},
+		"timeout":    {"1m30s"},
+		"Ignored":    {"x"},
+		"city":       {"shanghai"},
+	}
+	r := httptest.NewRequest(http.MethodPost, "/search?page=3", strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var form searchForm
+	if err := Form.Bind(r, &form); err != nil {
+		t.Fatal(err)
+	}
+	size := uint8(20)
+	until := time.Unix(1672617600, 0)
+	want := searchForm{
+		Keyword: "go",
+		Page:    3,
+		Size:    &size,
+		Tags:    []string{"web", "orm"},
+		Ids:     [2]int64{1, 2},
+		User:    map[string]string{"name": "cs", "age": "18"},
+		Scores:  map[string]int{"go": 90},
+		Since:   time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+		Until:   &until,
+		Timeout: 90 * time.Second,
+		address: address{City: "shanghai"},
+		Home:    &address{City: "shanghai"},
+	}
+	if !reflect.DeepEqual(form, want) {
+		t.Errorf("got %+v\nwant %+v", form, want)
+	}
+
+	//默认值以及类型错误
+	var query struct {
+		Page int `query:"page,default=1"`
+		Size int `query:"size"`
+	}
+	r = httptest.NewRequest(http.MethodGet, "/search?size=10", nil)
+	if err := Query.Bind(r, &query); err != nil || query.Page != 1 || query.Size != 10 {
+		t.Errorf("got %+v %v", query, err)
+	}
+	r = httptest.NewRequest(http.MethodGet, "/search?size=ten", nil)
+	if err := Query.Bind(r, &query); err == nil {
+		t.Error("expected error for invalid int")
+	}
+}
+
+type unexportedBase struct {
+	Id int `query:"id"`
+}
+
+type unexportedName string
+
+// 嵌入的未导出类型不能赋值，跳过它们而不是panic
+func TestQueryBindingUnexportedEmbedded(t *testing.T) {
+	var ptr struct {
+		*unexportedBase
+		unexportedName
+		Name string `query:"name"`
+	}
+	r := httptest.NewRequest(http.MethodGet, "/?id=7&name=cs&unexportedName=x", nil)
+	if err := Query.Bind(r, &ptr); err != nil || ptr.Name != "cs" || ptr.unexportedBase != nil || ptr.unexportedName != "" {
+		t.Errorf("got %+v %v", ptr, err)
+	}
+	//已经分配的指针和非指针的嵌入结构体仍然绑定导出的字段
+	ptr.unexportedBase = &unexportedBase{}
+	if err := Query.Bind(r, &ptr); err != nil || ptr.Id != 7 {
+		t.Errorf("got %+v %v", ptr, err)
+	}
+	var value struct {
+		unexportedBase
+	}
+	if err := Query.Bind(r, &value); err != nil || value.Id != 7 {
+		t.Errorf("got %+v %v", value, err)
+	}
+}
+
+func TestFormBindingValidate(t *testing.T) {
+	var form struct {
+		Name string `form:"name" validate:"required"`
+	}
+	r := httptest.NewRequest(http.MethodGet, "/?age=1", nil)
+	if err := Form.Bind(r, &form); err == nil {
+		t.Error("expected validation error")
+	}
+}
+
+func TestHeaderAndUriBinding(t *testing.T) {
+	var header struct {
+		RequestId string   `header:"x-request-id"`
+		Accept    []string `header:"Accept"`
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Request-Id", "abc")
+	r.Header.Add("Accept", "text/html")
+	r.Header.Add("Accept", "application/json")
+	if err := Header.Bind(r, &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.RequestId != "abc" || len(header.Accept) != 2 {
+		t.Errorf("got %+v", header)
+	}
+
+	var uri struct {
+		Id   int64  `uri:"id"`
+		Name string `uri:"name"`
+	}
+	if err := Uri.BindUri(map[string][]string{"id": {"7"}, "name": {"cs"}}, &uri); err != nil || uri.Id != 7 || uri.Name != "cs" {
+		t.Errorf("got %+v %v", uri, err)
+	}
+}
+
+func TestMultipartBinding(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("name", "cs")
+	for _, name := range []string{"a.txt", "b.txt"} {
+		fw, _ := mw.CreateFormFile("files", name)
+		fw.Write([]byte(name))
+	}
+	fw, _ := mw.CreateFormFile("avatar", "avatar.png")
+	fw.Write([]byte("png"))
+	mw.Close()
+
+	var form struct {
+		Name   string                  `form:"name"`
+		Avatar *multipart.FileHeader   `form:"avatar"`
+		Files  []*multipart.FileHeader `form:"files"`
+	}
+	r := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	if err := FormMultipart.Bind(r, &form); err != nil {
+		t.Fatal(err)
+	}
+	if form.Name != "cs" || form.Avatar.Filename != "avatar.png" || len(form.Files) != 2 || form.Files[1].Filename != "b.txt" {
+		t.Errorf("got %+v", form)
+	}
+}
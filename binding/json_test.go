@@ -0,0 +1,116 @@
+package binding
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type jsonAddress struct {
+	City   string `json:"city" csgo:"required"`
+	Street string `json:"street"`
+}
+
+type jsonBase struct {
+	Id int64 `json:"id" csgo:"required"`
+}
+
+type jsonUser struct {
+	jsonBase
+	Name      string                  `json:"name" csgo:"required"`
+	Address   *jsonAddress            `json:"address" csgo:"required"`
+	Addresses []jsonAddress           `json:"addresses"`
+	Tags      map[string]*jsonAddress `json:"tags"`
+	Created   time.Time               `json:"created"`
+}
+
+func TestJSONRequired(t *testing.T) {
+	tests := []struct {
+		body string
+		err  string
+	}{
+		{`{"id":9007199254740993,"name":"cs","address":{"city":"sh"},"created":"2023-01-02T00:00:00Z"}`, ""},
+		{`{"id":1,"address":{"city":"sh"}}`, "field [name] is not exist"},
+		{`{"name":"cs","address":{"city":"sh"}}`, "field [id] is not exist"},
+		{`{"id":1,"name":"cs","address":{"street":"x"}}`, "field [address.city] is not exist"},
+		{`{"id":1,"name":"cs","address":{"city":"sh"},"addresses":[{"city":"a"},{"street":"b"}]}`, "field [addresses[1].city] is not exist"},
+		{`{"id":1,"name":"cs","address":{"city":"sh"},"tags":{"home":{"street":"c"}}}`, "field [tags.home.city] is not exist"},
+		//必须的字段为null时当作不存在，其他字段为null时不检查内部的字段
+		{`{"id":1,"name":"cs","address":null}`, "field [address] is not exist"},
+		{`{"id":1,"name":null,"address":{"city":"sh"}}`, "field [name] is not exist"},
+		{`{"id":1,"name":"cs","address":{"city":"sh"},"addresses":null,"tags":{"home":null}}`, ""},
+		//忽略未知字段，类型错误由解码时返回
+		{`{"id":1,"name":"cs","address":{"city":"sh"},"other":{"city":[1,{}]}}`, ""},
+		{`{"id":"1","name":"cs","address":{"city":"sh"}}`, "json: cannot unmarshal string into Go struct field jsonUser.id of type int64"},
+	}
+	for _, tt := range tests {
+		var u jsonUser
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		err := NewJSON(false, true).Bind(r, &u)
+		if got := errString(err); got != tt.err {
+			t.Errorf("%s: got error %q, want %q", tt.body, got, tt.err)
+		}
+	}
+
+	//大整数不会因为经过map而丢失精度
+	var u jsonUser
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tests[0].body))
+	if err := NewJSON(true, true).Bind(r, &u); err != nil || u.Id != 9007199254740993 {
+		t.Errorf("got %d %v", u.Id, err)
+	}
+}
+
+// 一直返回数组元素的请求体
+type endlessArray struct{}
+
+func (endlessArray) Read(p []byte) (int, error) {
+	for i := 0; i+1 < len(p); i += 2 {
+		p[i], p[i+1] = '1', ','
+	}
+	return len(p) &^ 1, nil
+}
+
+// 检查出错时不再读取剩余的请求体
+func TestJSONRequiredStopsEarly(t *testing.T) {
+	var u struct {
+		Address jsonAddress `json:"address"`
+		Numbers []int       `json:"numbers"`
+	}
+	body := io.MultiReader(strings.NewReader(`{"address":{"street":"x"},"numbers":[`), endlessArray{})
+	r := httptest.NewRequest(http.MethodPost, "/", body)
+	if err := NewJSON(false, true).Bind(r, &u); errString(err) != "field [address.city] is not exist" {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestJSONRequiredSlice(t *testing.T) {
+	var users []jsonAddress
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"city":"a"},{"street":"b"}]`))
+	if err := NewJSON(false, true).Bind(r, &users); errString(err) != "field [[1].city] is not exist" {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestJSONDisallowUnknownFields(t *testing.T) {
+	body := `{"city":"sh","zip":"200000"}`
+	var a jsonAddress
+	if err := NewJSON(false, true).Bind(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)), &a); err != nil {
+		t.Errorf("got %v", err)
+	}
+	if err := NewJSON(true, true).Bind(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)), &a); err == nil {
+		t.Error("expected unknown field error")
+	}
+	if err := NewJSON(true, false).Bind(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)), &a); err == nil {
+		t.Error("expected unknown field error")
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,27 @@
+package binding
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type msgpackBinding struct{}
+
+func (msgpackBinding) Name() string {
+	return "msgpack"
+}
+
+// Bind 字段名优先使用msgpack标签，没有时使用json标签，和json绑定的结构体可以直接复用
+func (msgpackBinding) Bind(r *http.Request, obj any) error {
+	if r.Body == nil {
+		return errors.New("body is nil")
+	}
+	dec := msgpack.NewDecoder(r.Body)
+	dec.SetCustomStructTag("json")
+	if err := dec.Decode(obj); err != nil {
+		return err
+	}
+	return validate(r, obj)
+}
@@ -0,0 +1,31 @@
+package binding
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+)
+
+type protobufBinding struct{}
+
+func (protobufBinding) Name() string {
+	return "protobuf"
+}
+
+// Bind obj必须是protoc生成的消息类型，生成的结构体中没有校验标签，所以不做校验
+func (protobufBinding) Bind(r *http.Request, obj any) error {
+	if r.Body == nil {
+		return errors.New("body is nil")
+	}
+	msg, ok := obj.(proto.Message)
+	if !ok {
+		return errors.New("obj is not a proto.Message")
+	}
+	buf, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(buf, msg)
+}
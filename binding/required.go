@@ -0,0 +1,240 @@
+package binding
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	rawMessageType      = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// 结构体在json中的字段，按类型缓存
+type jsonFields struct {
+	fields   []jsonField
+	required []int
+}
+
+type jsonField struct {
+	name     string
+	typ      reflect.Type
+	required bool
+}
+
+var jsonFieldsCache sync.Map
+
+// 逐个读取json的token，检查 csgo:"required" 标签的字段是否存在，不需要先解码成map
+// 值为null的字段和不存在一样，会进入嵌套的结构体、指针、切片、数组和map，值为null时不再检查它的字段
+//
+//	type User struct {
+//		Name    string   `json:"name" csgo:"required"`
+//		Address *Address `json:"address" csgo:"required"`
+//		Tags    []Tag    `json:"tags"`
+//	}
+type requiredChecker struct {
+	dec *json.Decoder
+}
+
+func checkRequired(r io.Reader, t reflect.Type) error {
+	c := &requiredChecker{dec: json.NewDecoder(r)}
+	c.dec.UseNumber()
+	_, err := c.check(t, "")
+	return err
+}
+
+// 检查下一个值，返回值是否为null
+func (c *requiredChecker) check(t reflect.Type, path string) (bool, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	tok, err := c.dec.Token()
+	if err != nil {
+		return false, err
+	}
+	delim, isDelim := tok.(json.Delim)
+	if !isDelim {
+		//null或者普通的值，类型是否匹配由解码时检查
+		return tok == nil, nil
+	}
+	if hasCustomDecoder(t) {
+		return false, c.skip(delim)
+	}
+	switch {
+	case delim == '{' && t.Kind() == reflect.Struct:
+		return false, c.checkStruct(t, path)
+	case delim == '{' && t.Kind() == reflect.Map:
+		for c.dec.More() {
+			key, err := c.dec.Token()
+			if err != nil {
+				return false, err
+			}
+			if _, err := c.check(t.Elem(), joinPath(path, fmt.Sprint(key))); err != nil {
+				return false, err
+			}
+		}
+		_, err = c.dec.Token()
+		return false, err
+	case delim == '[' && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for i := 0; c.dec.More(); i++ {
+			if _, err := c.check(t.Elem(), path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return false, err
+			}
+		}
+		_, err = c.dec.Token()
+		return false, err
+	}
+	return false, c.skip(delim)
+}
+
+func (c *requiredChecker) checkStruct(t reflect.Type, path string) error {
+	info := cachedJSONFields(t)
+	seen := make([]bool, len(info.fields))
+	for c.dec.More() {
+		tok, err := c.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		i := info.index(key)
+		if i < 0 {
+			//未知字段是否允许由解码时的 DisallowUnknownFields 决定
+			if err := c.skipValue(); err != nil {
+				return err
+			}
+			continue
+		}
+		null, err := c.check(info.fields[i].typ, joinPath(path, info.fields[i].name))
+		if err != nil {
+			return err
+		}
+		//和原来解码成map后检查一样，null当作不存在
+		seen[i] = !null
+	}
+	if _, err := c.dec.Token(); err != nil {
+		return err
+	}
+	for _, i := range info.required {
+		if !seen[i] {
+			return fmt.Errorf("field [%s] is not exist", joinPath(path, info.fields[i].name))
+		}
+	}
+	return nil
+}
+
+// 跳过下一个值
+func (c *requiredChecker) skipValue() error {
+	tok, err := c.dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); ok {
+		return c.skip(delim)
+	}
+	return nil
+}
+
+// 已经读到了 { 或者 [，跳过到对应的结束位置
+func (c *requiredChecker) skip(delim json.Delim) error {
+	if delim != '{' && delim != '[' {
+		return nil
+	}
+	depth := 1
+	for depth > 0 {
+		tok, err := c.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+// 和encoding/json一样，先精确匹配字段名，再忽略大小写匹配
+func (f *jsonFields) index(key string) int {
+	for i, field := range f.fields {
+		if field.name == key {
+			return i
+		}
+	}
+	for i, field := range f.fields {
+		if strings.EqualFold(field.name, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+func cachedJSONFields(t reflect.Type) *jsonFields {
+	if f, ok := jsonFieldsCache.Load(t); ok {
+		return f.(*jsonFields)
+	}
+	f := &jsonFields{}
+	collectJSONFields(t, f)
+	for i, field := range f.fields {
+		if field.required {
+			f.required = append(f.required, i)
+		}
+	}
+	actual, _ := jsonFieldsCache.LoadOrStore(t, f)
+	return actual.(*jsonFields)
+}
+
+func collectJSONFields(t reflect.Type, f *jsonFields) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" && tag == "-" {
+			continue
+		}
+		//没有json标签的匿名结构体，字段提升到外层
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectJSONFields(ft, f)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		f.fields = append(f.fields, jsonField{
+			name:     name,
+			typ:      field.Type,
+			required: field.Tag.Get("csgo") == "required",
+		})
+	}
+}
+
+// 自己实现了解码的类型，不检查它的内部结构
+func hasCustomDecoder(t reflect.Type) bool {
+	if t == rawMessageType {
+		return true
+	}
+	pt := reflect.PointerTo(t)
+	return pt.Implements(jsonUnmarshalerType) || pt.Implements(unmarshalType)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,186 @@
+package binding
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	ut "github.com/go-playground/universal-translator"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// CustomValidator 可以注册自定义规则的验证器，默认的验证器和 NewValidator 创建的验证器都实现了它
+type CustomValidator interface {
+	StructValidator
+	// RegisterValidation 注册字段规则，比如注册 mobile 后可以使用 `validate:"mobile"`
+	RegisterValidation(tag string, fn validator.Func, callValidationEvenIfNull ...bool) error
+	// RegisterStructValidation 注册结构体级别的规则，types为规则作用的结构体类型的值
+	RegisterStructValidation(fn validator.StructLevelFunc, types ...any)
+	// RegisterAlias 注册一组规则的别名，比如 RegisterAlias("password", "required,min=8,max=32")
+	RegisterAlias(alias, tags string)
+	// RegisterMessage 注册规则在某种语言下的错误信息，{0}为字段名，{1}为规则的参数
+	RegisterMessage(tag, locale, message string) error
+}
+
+// ErrNotCustomValidator 全局的 Validator 被替换成了不支持注册规则的验证器
+var ErrNotCustomValidator = errors.New("binding: validator does not support custom rules")
+
+// RegisterValidation 注册字段规则，如果有名字相同的规则会覆盖它
+func (d *defaultValidator) RegisterValidation(tag string, fn validator.Func, callValidationEvenIfNull ...bool) error {
+	d.lazyInit()
+	return d.validate.RegisterValidation(tag, fn, callValidationEvenIfNull...)
+}
+
+// RegisterStructValidation 注册结构体级别的规则
+func (d *defaultValidator) RegisterStructValidation(fn validator.StructLevelFunc, types ...any) {
+	d.lazyInit()
+	d.validate.RegisterStructValidation(fn, types...)
+}
+
+// RegisterAlias 注册规则的别名，别名对应的规则校验失败时错误中的规则名是别名
+func (d *defaultValidator) RegisterAlias(alias, tags string) {
+	d.lazyInit()
+	d.validate.RegisterAlias(alias, tags)
+}
+
+// RegisterMessage 注册错误信息，默认的验证器支持en和zh
+func (d *defaultValidator) RegisterMessage(tag, locale, message string) error {
+	d.lazyInit()
+	trans, ok := d.uni.GetTranslator(locale)
+	if !ok {
+		return fmt.Errorf("binding: unsupported locale %q", locale)
+	}
+	return d.validate.RegisterTranslation(tag, trans, func(t ut.Translator) error {
+		return t.Add(tag, message, true)
+	}, func(t ut.Translator, fe validator.FieldError) string {
+		msg, err := t.T(fe.Tag(), fe.Field(), fe.Param())
+		if err != nil {
+			return fe.(error).Error()
+		}
+		return msg
+	})
+}
+
+// 注册到v上，v为nil时注册到全局的 Validator
+func customValidator(v StructValidator) (CustomValidator, error) {
+	if v == nil {
+		v = Validator
+	}
+	cv, ok := v.(CustomValidator)
+	if !ok {
+		return nil, ErrNotCustomValidator
+	}
+	return cv, nil
+}
+
+// RegisterValidation 在全局的 Validator 上注册字段规则
+func RegisterValidation(tag string, fn validator.Func, callValidationEvenIfNull ...bool) error {
+	cv, err := customValidator(nil)
+	if err != nil {
+		return err
+	}
+	return cv.RegisterValidation(tag, fn, callValidationEvenIfNull...)
+}
+
+// RegisterStructValidation 在全局的 Validator 上注册结构体级别的规则
+func RegisterStructValidation(fn validator.StructLevelFunc, types ...any) error {
+	cv, err := customValidator(nil)
+	if err != nil {
+		return err
+	}
+	cv.RegisterStructValidation(fn, types...)
+	return nil
+}
+
+// RegisterAlias 在全局的 Validator 上注册规则的别名
+func RegisterAlias(alias, tags string) error {
+	cv, err := customValidator(nil)
+	if err != nil {
+		return err
+	}
+	cv.RegisterAlias(alias, tags)
+	return nil
+}
+
+// RegisterMessage 在全局的 Validator 上注册规则的错误信息
+func RegisterMessage(tag, locale, message string) error {
+	cv, err := customValidator(nil)
+	if err != nil {
+		return err
+	}
+	return cv.RegisterMessage(tag, locale, message)
+}
+
+// RegisterRule 注册类型为T的字段的规则，fn的参数为字段的值和规则的参数，v为nil时注册到全局的 Validator
+// 字段的类型不是T时校验失败，比如
+//
+//	RegisterRule(nil, "prefix", func(s string, p string) bool { return strings.HasPrefix(s, p) })
+//
+// 之后可以使用 `validate:"prefix=cs_"`
+func RegisterRule[T any](v StructValidator, tag string, fn func(value T, param string) bool) error {
+	cv, err := customValidator(v)
+	if err != nil {
+		return err
+	}
+	return cv.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+		value, ok := fieldValue[T](fl.Field())
+		return ok && fn(value, fl.Param())
+	})
+}
+
+// RegisterCrossFieldRule 注册比较同一结构体中两个字段的规则，规则的参数是另一个字段的名字
+// 比如注册 after 后可以使用 `validate:"after=Start"`，fn的参数依次为当前字段和另一个字段的值
+// 另一个字段不存在或者类型不是T时校验失败
+func RegisterCrossFieldRule[T any](v StructValidator, tag string, fn func(value, other T) bool) error {
+	cv, err := customValidator(v)
+	if err != nil {
+		return err
+	}
+	return cv.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+		value, ok := fieldValue[T](fl.Field())
+		if !ok {
+			return false
+		}
+		field, _, found := fl.GetStructFieldOK()
+		if !found {
+			return false
+		}
+		other, ok := fieldValue[T](field)
+		return ok && fn(value, other)
+	})
+}
+
+// RegisterStructRule 注册结构体T的规则，用 sl.ReportError 报告校验失败的字段，T不能是指针
+func RegisterStructRule[T any](v StructValidator, fn func(sl validator.StructLevel, obj T)) error {
+	cv, err := customValidator(v)
+	if err != nil {
+		return err
+	}
+	var zero T
+	if reflect.TypeOf(zero) == nil || reflect.TypeOf(zero).Kind() != reflect.Struct {
+		return fmt.Errorf("binding: struct rule needs a struct type, got %T", zero)
+	}
+	cv.RegisterStructValidation(func(sl validator.StructLevel) {
+		if obj, ok := fieldValue[T](sl.Current()); ok {
+			fn(sl, obj)
+		}
+	}, zero)
+	return nil
+}
+
+// 取出字段的值，指针字段已经被验证器解引用，这里再兼容一次T本身是指针的情况
+func fieldValue[T any](field reflect.Value) (T, bool) {
+	var zero T
+	if !field.IsValid() || !field.CanInterface() {
+		return zero, false
+	}
+	if value, ok := field.Interface().(T); ok {
+		return value, true
+	}
+	if field.CanAddr() {
+		if value, ok := field.Addr().Interface().(T); ok {
+			return value, true
+		}
+	}
+	return zero, false
+}
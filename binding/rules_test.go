@@ -0,0 +1,110 @@
+package binding
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type ruleEvent struct {
+	Code  string `json:"code" validate:"prefix=ev_"`
+	Start int    `json:"start"`
+	End   int    `json:"end" validate:"after=Start"`
+	Pass  string `json:"pass" validate:"password"`
+	Again string `json:"again"`
+}
+
+func newRuleValidator(t *testing.T) CustomValidator {
+	v := NewValidator()
+	if err := RegisterRule(v, "prefix", func(s string, p string) bool { return strings.HasPrefix(s, p) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterCrossFieldRule(v, "after", func(value, other int) bool { return value > other }); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterStructRule(v, func(sl validator.StructLevel, e ruleEvent) {
+		if e.Pass != e.Again {
+			sl.ReportError(e.Again, "again", "Again", "eqpass", "")
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v.RegisterAlias("password", "min=4,max=8")
+	if err := v.RegisterMessage("prefix", "zh", "{0}必须以{1}开头"); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestCustomRules(t *testing.T) {
+	v := newRuleValidator(t)
+	body := `{"code":"x","start":5,"end":3,"pass":"ab","again":"cd"}`
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r = r.WithContext(WithValidator(r.Context(), v))
+	err := JSON.Bind(r, &ruleEvent{})
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("got %T %v", err, err)
+	}
+	var rules []string
+	for _, fe := range ve.Errors {
+		rules = append(rules, fe.Field+":"+fe.Rule)
+	}
+	want := []string{"code:prefix", "end:after", "pass:password", "again:eqpass"}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("got %v, want %v", rules, want)
+	}
+	if msg := ve.Translate("zh").Errors[0].Message; msg != "code必须以ev_开头" {
+		t.Errorf("got %q", msg)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"code":"ev_1","start":1,"end":2,"pass":"abcd","again":"abcd"}`))
+	r = r.WithContext(WithValidator(r.Context(), v))
+	if err := JSON.Bind(r, &ruleEvent{}); err != nil {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestCustomRulesNotGlobal(t *testing.T) {
+	newRuleValidator(t)
+	//规则只注册在新的验证器上，全局的验证器不认识 prefix
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"code":"x"}`))
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for undefined rule")
+		}
+	}()
+	_ = JSON.Bind(r, &ruleEvent{})
+}
+
+func TestRegisterOnGlobalValidator(t *testing.T) {
+	old := Validator
+	defer func() { Validator = old }()
+	Validator = NewValidator()
+	if err := RegisterValidation("even", func(fl validator.FieldLevel) bool { return fl.Field().Int()%2 == 0 }); err != nil {
+		t.Fatal(err)
+	}
+	var obj struct {
+		N int `query:"n" validate:"even"`
+	}
+	r := httptest.NewRequest(http.MethodGet, "/?n=3", nil)
+	var ve *ValidationError
+	if err := Query.Bind(r, &obj); !errors.As(err, &ve) || ve.Errors[0].Rule != "even" {
+		t.Errorf("got %v", err)
+	}
+
+	Validator = noopValidator{}
+	if err := RegisterAlias("x", "required"); err != ErrNotCustomValidator {
+		t.Errorf("got %v", err)
+	}
+}
+
+type noopValidator struct{}
+
+func (noopValidator) ValidateStruct(any) error { return nil }
+func (noopValidator) Engine() any              { return nil }
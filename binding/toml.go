@@ -0,0 +1,24 @@
+package binding
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BurntSushi/toml"
+)
+
+type tomlBinding struct{}
+
+func (tomlBinding) Name() string {
+	return "toml"
+}
+
+func (tomlBinding) Bind(r *http.Request, obj any) error {
+	if r.Body == nil {
+		return errors.New("body is nil")
+	}
+	if _, err := toml.NewDecoder(r.Body).Decode(obj); err != nil {
+		return err
+	}
+	return validate(r, obj)
+}
@@ -0,0 +1,134 @@
+package binding
+
+import (
+	"strconv"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// DefaultLocale 没有指定语言或者不支持指定的语言时，校验错误信息使用的语言
+var DefaultLocale = "en"
+
+// FieldError 一个字段校验失败的信息
+type FieldError struct {
+	//json中的字段路径，比如 address.city、items[0].name
+	Field string `json:"field"`
+	//校验失败的规则，比如 required、max
+	Rule string `json:"rule"`
+	//规则的参数，比如 max=10 中的10
+	Param string `json:"param,omitempty"`
+	//翻译后的错误信息
+	Message string `json:"message"`
+
+	fe validator.FieldError
+}
+
+// ValidationError 结构体校验失败时返回的错误，包含所有校验失败的字段
+type ValidationError struct {
+	Errors []FieldError
+
+	translator func(locale string) (ut.Translator, bool)
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	for i, fe := range e.Errors {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(fe.Field)
+		b.WriteString(": ")
+		b.WriteString(fe.Message)
+	}
+	return b.String()
+}
+
+// Translate 返回使用指定语言的错误信息，按顺序使用第一个支持的语言，比如 Translate("zh_CN", "zh")
+func (e *ValidationError) Translate(locales ...string) *ValidationError {
+	trans := e.findTranslator(locales)
+	errs := make([]FieldError, len(e.Errors))
+	for i, fe := range e.Errors {
+		fe.Message = translate(fe.fe, trans)
+		errs[i] = fe
+	}
+	return &ValidationError{Errors: errs, translator: e.translator}
+}
+
+func (e *ValidationError) findTranslator(locales []string) ut.Translator {
+	if e.translator == nil {
+		return nil
+	}
+	for _, locale := range locales {
+		if trans, ok := e.translator(locale); ok {
+			return trans
+		}
+	}
+	trans, _ := e.translator(DefaultLocale)
+	return trans
+}
+
+// 没有翻译时使用validator的英文信息
+func translate(fe validator.FieldError, trans ut.Translator) string {
+	if trans != nil {
+		return fe.Translate(trans)
+	}
+	if err, ok := fe.(error); ok {
+		return err.Error()
+	}
+	return fe.Tag()
+}
+
+// 把validator的错误转换成 ValidationError
+func newValidationError(errs validator.ValidationErrors, translator func(string) (ut.Translator, bool)) *ValidationError {
+	e := &ValidationError{Errors: make([]FieldError, 0, len(errs)), translator: translator}
+	trans := e.findTranslator(nil)
+	for _, fe := range errs {
+		e.Errors = append(e.Errors, FieldError{
+			Field:   fieldPath("", fe.Namespace()),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: translate(fe, trans),
+			fe:      fe,
+		})
+	}
+	return e
+}
+
+// Namespace 形如 User.address.city，第一段是结构体的名字，去掉后就是json中的路径
+// 切片中元素的错误在前面加上元素的位置，比如 [1].name、[1][0].name
+func fieldPath(prefix, namespace string) string {
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		namespace = namespace[i+1:]
+	}
+	if prefix == "" || strings.HasPrefix(namespace, "[") {
+		return prefix + namespace
+	}
+	return prefix + "." + namespace
+}
+
+// 把校验器返回的错误转换成 ValidationError，切片中每个元素的错误合并到一起
+func toValidationError(err error, translator func(string) (ut.Translator, bool)) error {
+	switch e := err.(type) {
+	case validator.ValidationErrors:
+		return newValidationError(e, translator)
+	case SliceValidationError:
+		merged := &ValidationError{translator: translator}
+		for i, elemErr := range e {
+			if elemErr == nil {
+				continue
+			}
+			ve, ok := toValidationError(elemErr, translator).(*ValidationError)
+			if !ok {
+				return err
+			}
+			for _, fe := range ve.Errors {
+				fe.Field = fieldPath("["+strconv.Itoa(i)+"]", "."+fe.Field)
+				merged.Errors = append(merged.Errors, fe)
+			}
+		}
+		return merged
+	}
+	return err
+}
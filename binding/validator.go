@@ -1,18 +1,26 @@
 package binding
 
 import (
+	"context"
 	"fmt"
-	"github.com/go-playground/validator"
+	"net/http"
 	"reflect"
 	"strings"
 	"sync"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"gopkg.in/go-playground/validator.v9"
+	enTranslations "gopkg.in/go-playground/validator.v9/translations/en"
+	zhTranslations "gopkg.in/go-playground/validator.v9/translations/zh"
 )
 
 // StructValidator 验证器接口
 type StructValidator interface {
 	// ValidateStruct 结构体验证，如果错误返回对应的错误信息
 	ValidateStruct(any) error
-	// Engine 返回对应使用的验证器
+	// Engine 返回对应使用的验证器，默认的验证器返回 gopkg.in/go-playground/validator.v9 的 *validator.Validate
 	Engine() any
 }
 
@@ -21,6 +29,14 @@ type defaultValidator struct {
 	//单例表示
 	one      sync.Once
 	validate *validator.Validate
+	//错误信息的翻译，支持英文和中文
+	uni *ut.UniversalTranslator
+}
+
+// TranslatableValidator 可以翻译错误信息的验证器，校验失败时返回的 ValidationError 使用它翻译
+type TranslatableValidator interface {
+	// Translator 返回语言对应的翻译器，不支持时返回false
+	Translator(locale string) (ut.Translator, bool)
 }
 
 // Validator 采用默认的验证器
@@ -31,18 +47,24 @@ func (d *defaultValidator) ValidateStruct(obj any) error {
 	of := reflect.ValueOf(obj)
 	switch of.Kind() {
 	case reflect.Pointer:
-		return d.validateStruct(of.Elem().Interface())
+		if of.IsNil() {
+			return nil
+		}
+		return d.ValidateStruct(of.Elem().Interface())
 	case reflect.Struct:
-		return d.ValidateStruct(obj)
+		return d.validateStruct(obj)
 	case reflect.Slice, reflect.Array:
+		//按元素的位置记录错误，校验通过的元素为nil
 		count := of.Len()
-		sliceValidationError := make(SliceValidationError, 0)
+		sliceValidationError := make(SliceValidationError, count)
+		failed := false
 		for i := 0; i < count; i++ {
-			if err := d.validateStruct(of.Index(i).Interface()); err != nil {
-				sliceValidationError = append(sliceValidationError, err)
+			if err := d.ValidateStruct(of.Index(i).Interface()); err != nil {
+				sliceValidationError[i] = err
+				failed = true
 			}
 		}
-		if len(sliceValidationError) == 0 {
+		if !failed {
 			return nil
 		}
 		return sliceValidationError
@@ -50,12 +72,52 @@ func (d *defaultValidator) ValidateStruct(obj any) error {
 	return nil
 }
 
+// NewValidator 创建一个新的默认验证器，注册在上面的规则不影响全局的 Validator
+func NewValidator() CustomValidator {
+	return &defaultValidator{}
+}
+
+type validatorKey struct{}
+
+// WithValidator 返回带有验证器的context，绑定器从请求的context中取出它代替全局的 Validator
+func WithValidator(ctx context.Context, v StructValidator) context.Context {
+	return context.WithValue(ctx, validatorKey{}, v)
+}
+
+// 请求使用的验证器，没有单独设置时使用全局的 Validator
+func validatorFor(r *http.Request) StructValidator {
+	if r != nil {
+		if v, ok := r.Context().Value(validatorKey{}).(StructValidator); ok && v != nil {
+			return v
+		}
+	}
+	return Validator
+}
+
 // 将obj转换成struct后才执行插件校验，如果obj是切片，那么循环执行
-func validate(obj any) error {
-	return Validator.ValidateStruct(obj)
+// 校验失败时返回 *ValidationError
+func validate(r *http.Request, obj any) error {
+	return validateWith(validatorFor(r), obj)
 }
 
-// Engine 默认的验证器实现了接口方法
+func validateWith(v StructValidator, obj any) error {
+	if v == nil {
+		return nil
+	}
+	err := v.ValidateStruct(obj)
+	if err == nil {
+		return nil
+	}
+	var translator func(string) (ut.Translator, bool)
+	if t, ok := v.(TranslatableValidator); ok {
+		translator = t.Translator
+	}
+	return toValidationError(err, translator)
+}
+
+// Engine 默认的验证器实现了接口方法，返回 gopkg.in/go-playground/validator.v9 的 *validator.Validate
+// 注意不是 github.com/go-playground/validator 包中的类型，两者路径不同，类型断言时要导入前者
+// 换成前者是因为内置的中英文翻译包只能注册到 gopkg.in 路径下的验证器上
 func (d *defaultValidator) Engine() any {
 	d.lazyInit()
 	return d.validate
@@ -65,9 +127,39 @@ func (d *defaultValidator) Engine() any {
 func (d *defaultValidator) lazyInit() {
 	d.one.Do(func() {
 		d.validate = validator.New()
+		//错误信息中使用json中的字段名
+		d.validate.RegisterTagNameFunc(jsonFieldName)
+		enLocale := en.New()
+		d.uni = ut.New(enLocale, enLocale, zh.New())
+		enTrans, _ := d.uni.GetTranslator("en")
+		zhTrans, _ := d.uni.GetTranslator("zh")
+		if err := enTranslations.RegisterDefaultTranslations(d.validate, enTrans); err != nil {
+			panic(err)
+		}
+		if err := zhTranslations.RegisterDefaultTranslations(d.validate, zhTrans); err != nil {
+			panic(err)
+		}
 	})
 }
 
+// Translator 默认的验证器支持en和zh
+func (d *defaultValidator) Translator(locale string) (ut.Translator, bool) {
+	d.lazyInit()
+	return d.uni.FindTranslator(locale)
+}
+
+// json标签中的字段名，没有标签时使用字段名，json:"-" 的字段不出现在错误信息中
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
 func (d *defaultValidator) validateStruct(obj any) error {
 	d.lazyInit()
 	return d.validate.Struct(obj)
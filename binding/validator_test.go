@@ -0,0 +1,84 @@
+package binding
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type validateItem struct {
+	Name  string `json:"name" validate:"required"`
+	Count int    `json:"count" validate:"max=10"`
+}
+
+type validateOrder struct {
+	Id    string         `json:"id" validate:"required"`
+	Items []validateItem `json:"items" validate:"dive"`
+	Note  string         `validate:"max=5"`
+}
+
+func TestValidationError(t *testing.T) {
+	body := `{"items":[{"name":"a","count":1},{"count":11}],"Note":"too long"}`
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	err := JSON.Bind(r, &validateOrder{})
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("got %T %v", err, err)
+	}
+	want := []FieldError{
+		{Field: "id", Rule: "required", Message: "id is a required field"},
+		{Field: "items[1].name", Rule: "required", Message: "name is a required field"},
+		{Field: "items[1].count", Rule: "max", Param: "10", Message: "count must be 10 or less"},
+		{Field: "Note", Rule: "max", Param: "5", Message: "Note must be a maximum of 5 characters in length"},
+	}
+	if got := stripRaw(ve.Errors); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	zh := ve.Translate("zh_CN", "zh")
+	if zh.Errors[0].Message != "id为必填字段" || zh.Errors[2].Message != "count必须小于或等于10" {
+		t.Errorf("got %+v", zh.Errors)
+	}
+	//不支持的语言使用默认语言
+	if fr := ve.Translate("fr"); fr.Errors[0].Message != "id is a required field" {
+		t.Errorf("got %+v", fr.Errors)
+	}
+}
+
+func TestSliceValidationError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"name":"a"},{"name":"b","count":20},{}]`))
+	var items []validateItem
+	err := JSON.Bind(r, &items)
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("got %T %v", err, err)
+	}
+	var fields []string
+	for _, fe := range ve.Errors {
+		fields = append(fields, fe.Field)
+	}
+	if want := []string{"[1].count", "[2].name"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("got %v, want %v", fields, want)
+	}
+}
+
+func stripRaw(errs []FieldError) []FieldError {
+	out := make([]FieldError, len(errs))
+	for i, fe := range errs {
+		fe.fe = nil
+		out[i] = fe
+	}
+	return out
+}
+
+// Engine 返回 gopkg.in 路径下的验证器，断言成 github.com/go-playground/validator 的类型会失败
+func TestValidatorEngine(t *testing.T) {
+	if _, ok := NewValidator().Engine().(*validator.Validate); !ok {
+		t.Errorf("got %T", NewValidator().Engine())
+	}
+}
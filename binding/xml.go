@@ -22,5 +22,5 @@ func (x xmlBinding) Bind(r *http.Request, obj any) error {
 	if err := decoder.Decode(obj); err != nil {
 		return err
 	}
-	return validate(obj)
+	return validate(r, obj)
 }
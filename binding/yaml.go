@@ -0,0 +1,24 @@
+package binding
+
+import (
+	"errors"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+type yamlBinding struct{}
+
+func (yamlBinding) Name() string {
+	return "yaml"
+}
+
+func (yamlBinding) Bind(r *http.Request, obj any) error {
+	if r.Body == nil {
+		return errors.New("body is nil")
+	}
+	if err := yaml.NewDecoder(r.Body).Decode(obj); err != nil {
+		return err
+	}
+	return validate(r, obj)
+}
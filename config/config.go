@@ -4,6 +4,7 @@ import (
 	"flag"
 	"github.com/BurntSushi/toml"
 	"os"
+	"strings"
 	csLog "web/csgo/log"
 )
 
@@ -18,6 +19,7 @@ type CsConfig struct {
 	Template map[string]any
 	Redis    map[string]any
 	Mysql    map[string]any
+	Server   map[string]any
 }
 
 //默认初始化的方法
@@ -27,18 +29,37 @@ func init() {
 
 func loadToml() {
 	//默认的配置，若是自己不配置，那么使用默认配置路径
-	configFile := flag.String("conf", "conf/app.toml", "app config file")
-	flag.Parse()
+	flag.String("conf", "conf/app.toml", "app config file")
+	//init阶段不能调用flag.Parse，否则会拒绝go test等程序之后才注册的参数
+	configFile := confPath(os.Args[1:], "conf/app.toml")
 	//判断是否存在对应路径上的文件
-	if _, err := os.Stat(*configFile); err != nil {
+	if _, err := os.Stat(configFile); err != nil {
 		Conf.logger.Info("conf/app.toml file not load,because not exist")
 		return
 	}
 	//通过插件读取配置文件的内容,并且解码到Conf结构体中对应的属性
-	_, err := toml.DecodeFile(*configFile, Conf)
+	_, err := toml.DecodeFile(configFile, Conf)
 	if err != nil {
 		Conf.logger.Info("conf/app.toml file not load")
 		return
 	}
 
 }
+
+// confPath 从命令行参数中找到 -conf 的值，支持 -conf x、-conf=x 以及 -- 前缀的写法
+// 和flag包一样，遇到第一个不以-开头的参数或者单独的 -- 后不再解析，之后的参数都不是选项
+func confPath(args []string, defaultPath string) string {
+	for i := 0; i < len(args); i++ {
+		if len(args[i]) < 2 || args[i][0] != '-' || args[i] == "--" {
+			break
+		}
+		arg := strings.TrimPrefix(strings.TrimPrefix(args[i], "-"), "-")
+		if arg == "conf" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, "conf=") {
+			return strings.TrimPrefix(arg, "conf=")
+		}
+	}
+	return defaultPath
+}
@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestConfPath(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "conf/app.toml"},
+		{[]string{"-conf", "a.toml"}, "a.toml"},
+		{[]string{"--conf=b.toml"}, "b.toml"},
+		{[]string{"-test.v", "-conf=c.toml", "x"}, "c.toml"},
+		//第一个不是选项的参数之后都不解析，比如子命令的参数
+		{[]string{"serve", "-conf", "x.toml"}, "conf/app.toml"},
+		{[]string{"-", "-conf", "x.toml"}, "conf/app.toml"},
+		{[]string{"-conf"}, "conf/app.toml"},
+		//-- 之后的参数不是选项
+		{[]string{"--", "-conf", "d.toml"}, "conf/app.toml"},
+		{[]string{"-conf=e.toml", "--", "-conf=f.toml"}, "e.toml"},
+	}
+	for _, test := range tests {
+		if got := confPath(test.args, "conf/app.toml"); got != test.want {
+			t.Errorf("%v: got %q, want %q", test.args, got, test.want)
+		}
+	}
+}
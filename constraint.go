@@ -0,0 +1,74 @@
+package csgo
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 参数约束，写在参数名后面的尖括号中，比如 /user/:id<int>、/file/:name<[a-z]+\.txt>
+// 尖括号中是内置的类型名时按类型校验，否则作为正则表达式匹配整段路径
+type paramConstraint struct {
+	name  string
+	check func(value string) bool
+}
+
+// 内置的类型约束
+var typedConstraints = map[string]func(value string) bool{
+	"int": func(value string) bool {
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	},
+	"uint": func(value string) bool {
+		_, err := strconv.ParseUint(value, 10, 64)
+		return err == nil
+	},
+	"float": func(value string) bool {
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	},
+	"bool": func(value string) bool {
+		_, err := strconv.ParseBool(value)
+		return err == nil
+	},
+	"alpha": regexp.MustCompile(`^[a-zA-Z]+$`).MatchString,
+	"uuid":  regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`).MatchString,
+}
+
+// 约束为nil时匹配任意值
+func (c *paramConstraint) match(value string) bool {
+	return c == nil || c.check(value)
+}
+
+// 解析路由中的参数段，返回参数名和约束
+func parseParam(seg string) (string, *paramConstraint, error) {
+	if seg == "*" {
+		return seg, nil, nil
+	}
+	key := seg[1:]
+	var constraint *paramConstraint
+	if i := strings.IndexByte(key, '<'); i >= 0 {
+		if key[len(key)-1] != '>' {
+			return "", nil, errors.New("约束缺少结尾的 >")
+		}
+		name := key[i+1 : len(key)-1]
+		key = key[:i]
+		if name == "" {
+			return "", nil, errors.New("约束不能为空")
+		}
+		check, ok := typedConstraints[name]
+		if !ok {
+			re, err := regexp.Compile("^(?:" + name + ")$")
+			if err != nil {
+				return "", nil, err
+			}
+			check = re.MatchString
+		}
+		constraint = &paramConstraint{name: name, check: check}
+	}
+	if key == "" {
+		return "", nil, errors.New("参数缺少名字")
+	}
+	return key, constraint, nil
+}
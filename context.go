@@ -5,10 +5,12 @@ import (
 	"html/template"
 	"io"
 	"log"
+	"math"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"web/csgo/binding"
@@ -17,8 +19,12 @@ import (
 )
 
 const defaultMaxMemory = 32 << 20 //32M
+
+// 调用Abort后index被设置为这个值，处理链不再继续
+const abortIndex = math.MaxInt32
+
 type Context struct {
-	W http.ResponseWriter
+	W ResponseWriter
 	R *http.Request
 	//加载资源
 	engine *Engine
@@ -30,7 +36,7 @@ type Context struct {
 	DisallowUnknownFields bool
 	//是否开启校验json参数是否不够（没有满足对应的结构体）
 	IsValidate bool
-	//status
+	//Render和AbortWithStatus设置的状态码，实际发送的状态码使用 W.Status()
 	StatusCode int
 
 	//日志打印
@@ -41,6 +47,93 @@ type Context struct {
 	mu sync.RWMutex
 	//
 	sameSite http.SameSite
+	//路由匹配时捕获的路径参数
+	params Params
+	//匹配到的完整路由
+	fullPath string
+	//处理链以及当前执行到的位置
+	handlers HandlersChain
+	index    int
+	//原始的请求体，以及请求体是否超过了限制
+	rawBody      io.ReadCloser
+	bodyTooLarge bool
+	//解析multipart表单时最多使用的内存
+	maxMultipartMemory int64
+	writermem          responseWriter
+}
+
+// 从池中取出的ctx在处理新请求前，清空上一个请求留下的数据
+func (c *Context) reset(w http.ResponseWriter, r *http.Request) {
+	c.writermem.reset(w)
+	c.W = &c.writermem
+	c.R = r
+	c.queryCache = nil
+	c.formCache = nil
+	c.DisallowUnknownFields = false
+	c.IsValidate = false
+	c.StatusCode = 0
+	c.sameSite = 0
+	c.Keys = nil
+	c.params = nil
+	c.fullPath = ""
+	c.handlers = nil
+	c.index = -1
+	c.rawBody = r.Body
+	c.bodyTooLarge = false
+	c.maxMultipartMemory = c.engine.MaxMultipartMemory
+	c.limitBody(c.engine.MaxBodyBytes)
+}
+
+// 从头开始执行处理链
+func (c *Context) run(handlers HandlersChain) {
+	c.handlers = handlers
+	c.index = -1
+	c.Next()
+}
+
+// Next 在中间件中调用，执行处理链中后续的处理方法，全部执行完后返回
+// 中间件没有调用Next时，返回后也会继续执行后续的处理方法
+func (c *Context) Next() {
+	c.index++
+	for c.index < len(c.handlers) {
+		c.handlers[c.index](c)
+		c.index++
+	}
+}
+
+// Abort 中止处理链，后续的处理方法不再执行，当前的处理方法会正常执行完
+func (c *Context) Abort() {
+	c.index = abortIndex
+}
+
+// IsAborted 处理链是否被中止
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
+// AbortWithStatus 写入状态码并中止处理链
+func (c *Context) AbortWithStatus(code int) {
+	c.W.WriteHeader(code)
+	c.W.WriteHeaderNow()
+	c.StatusCode = code
+	c.Abort()
+}
+
+// AbortWithStatusJSON 返回json数据并中止处理链
+func (c *Context) AbortWithStatusJSON(code int, obj any) error {
+	c.Abort()
+	return c.JSON(code, obj)
+}
+
+// 中止处理链并通过引擎的错误处理方法返回错误，没有注册错误处理方法时返回code和错误信息
+func (c *Context) abortWithError(code int, err error) {
+	c.Abort()
+	if c.engine.errorHandler != nil {
+		code, data := c.engine.errorHandler(err)
+		c.JSON(code, data)
+		return
+	}
+	c.String(code, "%s\n", err)
 }
 
 func (c *Context) SetSameSite(s http.SameSite) {
@@ -61,6 +154,52 @@ func (c *Context) Get(key string) (value any, ok bool) {
 	c.mu.RUnlock()
 	return
 }
+
+// Param 获得路径参数，比如路由 /user/:id 中的id，路由 /static/** 中的**
+func (c *Context) Param(name string) string {
+	return c.params.ByName(name)
+}
+
+// Params 获得全部的路径参数
+func (c *Context) Params() Params {
+	return c.params
+}
+
+// ParamInt 获得int类型的路径参数，配合 :id<int> 约束使用时不需要再校验，转换失败返回0
+func (c *Context) ParamInt(name string) int {
+	value, _ := strconv.Atoi(c.Param(name))
+	return value
+}
+
+// ParamInt64 获得int64类型的路径参数，转换失败返回0
+func (c *Context) ParamInt64(name string) int64 {
+	value, _ := strconv.ParseInt(c.Param(name), 10, 64)
+	return value
+}
+
+// ParamUint64 获得uint64类型的路径参数，配合 :id<uint> 约束使用，转换失败返回0
+func (c *Context) ParamUint64(name string) uint64 {
+	value, _ := strconv.ParseUint(c.Param(name), 10, 64)
+	return value
+}
+
+// ParamFloat64 获得float64类型的路径参数，配合 :n<float> 约束使用，转换失败返回0
+func (c *Context) ParamFloat64(name string) float64 {
+	value, _ := strconv.ParseFloat(c.Param(name), 64)
+	return value
+}
+
+// ParamBool 获得bool类型的路径参数，配合 :b<bool> 约束使用，转换失败返回false
+func (c *Context) ParamBool(name string) bool {
+	value, _ := strconv.ParseBool(c.Param(name))
+	return value
+}
+
+// FullPath 获得匹配到的完整路由，比如 /user/get/:id，没有匹配到时为空串
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
 func (c *Context) SetBasicAuth(username, password string) {
 	c.R.Header.Set("Authorization", "Basic "+BasicAuth(username, password))
 }
@@ -124,10 +263,23 @@ func (c *Context) get(cache map[string][]string, key string) (map[string]string,
 	}
 	return dicts, exist
 }
+
+// 按照路由的内存限制解析multipart表单，请求体超过限制时返回 ErrBodyTooLarge
+func (c *Context) parseMultipartForm() error {
+	err := c.R.ParseMultipartForm(c.maxMultipartMemory)
+	if c.bodyTooLarge {
+		return ErrBodyTooLarge
+	}
+	return err
+}
+
 func (c *Context) initPostFormCache() {
 	if c.R != nil {
 		//对表单文件进行解析
-		if err := c.R.ParseMultipartForm(defaultMaxMemory); err != nil {
+		if err := c.parseMultipartForm(); err != nil {
+			if errors.Is(err, ErrBodyTooLarge) {
+				c.abortBodyTooLarge()
+			}
 			//如果表单不是文件，那么就会报错，但有时候是正常异常
 			if errors.Is(err, http.ErrNotMultipart) {
 				//如果异常不是解析异常 打印异常
@@ -170,6 +322,11 @@ func (c *Context) PostFormMap(key string) (dicts map[string]string) {
 }
 
 func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	if c.R.MultipartForm == nil {
+		if err := c.parseMultipartForm(); errors.Is(err, ErrBodyTooLarge) {
+			return nil, err
+		}
+	}
 	file, header, err := c.R.FormFile(name)
 	if err != nil {
 		log.Print(err)
@@ -206,7 +363,7 @@ func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error
 
 // MultipartForm 获得form中所有解析
 func (c *Context) MultipartForm() (*multipart.Form, error) {
-	err := c.R.ParseMultipartForm(defaultMaxMemory)
+	err := c.parseMultipartForm()
 	return c.R.MultipartForm, err
 }
 
@@ -267,6 +424,39 @@ func (c *Context) XML(status int, data any) error {
 	return err
 }
 
+// YAML 以application/yaml返回data
+func (c *Context) YAML(status int, data any) error {
+	return c.Render(status, &render.YAML{Data: data})
+}
+
+// TOML 以application/toml返回data，data必须是结构体或者map
+func (c *Context) TOML(status int, data any) error {
+	return c.Render(status, &render.TOML{Data: data})
+}
+
+// MsgPack 以application/msgpack返回data
+func (c *Context) MsgPack(status int, data any) error {
+	return c.Render(status, &render.MsgPack{Data: data})
+}
+
+// ProtoBuf 以application/x-protobuf返回data，data必须是protoc生成的消息类型
+func (c *Context) ProtoBuf(status int, data any) error {
+	return c.Render(status, &render.ProtoBuf{Data: data})
+}
+
+// JSONStream 以json数组的形式逐个输出items中的元素，每个元素都刷新到客户端
+// 客户端断开连接时停止输出并返回ctx的错误，比如
+//
+//	ctx.JSONStream(http.StatusOK, render.SliceItems(rows))
+func (c *Context) JSONStream(status int, items render.Items) error {
+	return c.Render(status, &render.JSONStream{Ctx: c.R.Context(), Items: items})
+}
+
+// NDJSONStream 和 JSONStream 相同，但是每行输出一个json对象，Content-Type为application/x-ndjson
+func (c *Context) NDJSONStream(status int, items render.Items) error {
+	return c.Render(status, &render.JSONStream{Ctx: c.R.Context(), Items: items, NDJSON: true})
+}
+
 func (c *Context) File(fileName string) {
 	http.ServeFile(c.W, c.R, fileName)
 }
@@ -321,8 +511,98 @@ func (c *Context) BindJson(obj any) error {
 	return c.MustBindWith(obj, json)
 }
 
+// BindYaml 绑定yaml格式的请求体，结构体字段使用yaml标签
+func (c *Context) BindYaml(obj any) error {
+	return c.MustBindWith(obj, binding.YAML)
+}
+
+// BindToml 绑定toml格式的请求体，结构体字段使用toml标签
+func (c *Context) BindToml(obj any) error {
+	return c.MustBindWith(obj, binding.TOML)
+}
+
+// BindMsgPack 绑定msgpack格式的请求体，结构体字段使用msgpack标签，没有时使用json标签
+func (c *Context) BindMsgPack(obj any) error {
+	return c.MustBindWith(obj, binding.MsgPack)
+}
+
+// BindProtoBuf 绑定protobuf格式的请求体，obj必须是protoc生成的消息类型
+func (c *Context) BindProtoBuf(obj any) error {
+	return c.MustBindWith(obj, binding.ProtoBuf)
+}
+
+// Bind 根据请求方法和Content-Type选择绑定器，不支持的Content-Type返回415，其他错误返回400
+// 可以用 binding.Register 添加其他Content-Type的绑定器
+func (c *Context) Bind(obj any) error {
+	b, err := binding.Default(c.R.Method, c.ContentType())
+	if err != nil {
+		c.abortWithError(http.StatusUnsupportedMediaType, err)
+		return err
+	}
+	return c.MustBindWith(obj, b)
+}
+
+// ContentType 请求的Content-Type，不包含charset等参数
+func (c *Context) ContentType() string {
+	contentType := c.R.Header.Get("Content-Type")
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.TrimSpace(contentType)
+}
+
+// BindQuery 绑定查询参数，结构体字段使用query标签
+func (c *Context) BindQuery(obj any) error {
+	return c.MustBindWith(obj, binding.Query)
+}
+
+// BindForm 绑定查询参数和表单参数，结构体字段使用form标签
+func (c *Context) BindForm(obj any) error {
+	return c.MustBindWith(obj, binding.Form)
+}
+
+// BindMultipart 绑定multipart表单中的参数和文件，结构体字段使用form标签
+func (c *Context) BindMultipart(obj any) error {
+	return c.MustBindWith(obj, binding.FormMultipart)
+}
+
+// BindHeader 绑定请求头，结构体字段使用header标签
+func (c *Context) BindHeader(obj any) error {
+	return c.MustBindWith(obj, binding.Header)
+}
+
+// BindUri 绑定路径参数，结构体字段使用uri标签，失败时返回400
+func (c *Context) BindUri(obj any) error {
+	if err := c.ShouldBindUri(obj); err != nil {
+		c.W.WriteHeader(http.StatusBadRequest)
+		return err
+	}
+	return nil
+}
+
+// ShouldBindUri 绑定路径参数，比如路由 /user/:id 对应字段 `uri:"id"`
+func (c *Context) ShouldBindUri(obj any) error {
+	params := make(map[string][]string, len(c.params))
+	for _, p := range c.params {
+		params[p.Key] = []string{p.Value}
+	}
+	return binding.Uri.BindUriWith(params, obj, c.validator())
+}
+
+// 绑定参数时使用的验证器，引擎上没有设置时使用全局的验证器
+func (c *Context) validator() binding.StructValidator {
+	if c.engine != nil && c.engine.Validator != nil {
+		return c.engine.Validator
+	}
+	return binding.Validator
+}
+
 func (c *Context) MustBindWith(obj any, bind binding.Binding) error {
 	if err := c.ShouldBind(obj, bind); err != nil {
+		if errors.Is(err, ErrBodyTooLarge) {
+			c.abortBodyTooLarge()
+			return err
+		}
 		// return 400 to behalf index is not match
 		c.W.WriteHeader(http.StatusBadRequest)
 		return err
@@ -330,7 +610,24 @@ func (c *Context) MustBindWith(obj any, bind binding.Binding) error {
 	return nil
 }
 func (c *Context) ShouldBind(obj any, bind binding.Binding) error {
-	return bind.Bind(c.R, obj)
+	//表单先按照路由的内存限制解析，绑定器不会再重复解析
+	if bind == binding.Form || bind == binding.FormMultipart {
+		c.parseMultipartForm()
+	}
+	//json绑定器使用ctx上的配置
+	if bind == binding.JSON {
+		bind = binding.NewJSON(c.DisallowUnknownFields, c.IsValidate)
+	}
+	r := c.R
+	if c.engine != nil && c.engine.Validator != nil {
+		r = r.WithContext(binding.WithValidator(r.Context(), c.engine.Validator))
+	}
+	err := bind.Bind(r, obj)
+	//绑定器可能会忽略读取请求体时的错误，以记录的结果为准
+	if c.bodyTooLarge {
+		return ErrBodyTooLarge
+	}
+	return err
 }
 
 func (c *Context) Fail(code int, msg string) {
@@ -347,6 +644,36 @@ func (c *Context) HandleWithError(statusCode int, obj any, err error) {
 	c.JSON(statusCode, obj)
 }
 
+// Problem RFC 7807 格式的错误信息
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	//校验失败的字段
+	Errors []binding.FieldError `json:"errors,omitempty"`
+}
+
+// ValidationProblem 把绑定参数时返回的错误渲染成 application/problem+json 并中止处理链
+// 校验错误返回422并列出每个字段的错误，错误信息的语言根据请求头Accept-Language选择，其他错误返回400
+func (c *Context) ValidationProblem(err error) error {
+	c.Abort()
+	problem := Problem{Type: "about:blank", Instance: c.R.URL.Path}
+	var ve *binding.ValidationError
+	if errors.As(err, &ve) {
+		ve = ve.Translate(acceptLanguages(c.R.Header.Get("Accept-Language"))...)
+		problem.Status = http.StatusUnprocessableEntity
+		problem.Detail = ve.Error()
+		problem.Errors = ve.Errors
+	} else {
+		problem.Status = http.StatusBadRequest
+		problem.Detail = err.Error()
+	}
+	problem.Title = http.StatusText(problem.Status)
+	return c.Render(problem.Status, render.ProblemJSON{Data: problem})
+}
+
 func (c *Context) SetCookie(name, value string, maxAge int, path, domain string, secure, httpOnly bool) {
 	if path == "" {
 		path = "/"
@@ -5,7 +5,11 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"sync"
+	"web/csgo/binding"
 	"web/csgo/config"
 	csLog "web/csgo/log"
 	"web/csgo/render"
@@ -17,8 +21,34 @@ const ANY = "ANY"
 type HandleFunc func(ctx *Context)
 
 // MiddlewareFunc 中间件，在处理方法前后进行调用
+// 不调用传入的处理方法时，处理链被中止，ctx.IsAborted() 返回true
 type MiddlewareFunc func(handleFunc HandleFunc) HandleFunc
 
+// HandlersChain 处理链，依次是中间件和最终的处理方法，中间件中调用 ctx.Next() 执行后续的处理方法
+type HandlersChain []HandleFunc
+
+// 将包装式的中间件转换为处理链中的一环，包装在注册时完成，处理请求时不再分配
+// 中间件没有调用next，或者后续的处理方法panic后被中间件恢复，处理链都不再继续
+func wrapMiddleware(middlewareFunc MiddlewareFunc) HandleFunc {
+	h := middlewareFunc(func(ctx *Context) {
+		ctx.Next()
+	})
+	return func(ctx *Context) {
+		h(ctx)
+		if ctx.index < len(ctx.handlers) {
+			ctx.Abort()
+		}
+	}
+}
+
+func wrapMiddlewares(middlewareFunc []MiddlewareFunc) HandlersChain {
+	handlers := make(HandlersChain, len(middlewareFunc))
+	for i, m := range middlewareFunc {
+		handlers[i] = wrapMiddleware(m)
+	}
+	return handlers
+}
+
 type ErrorHandler func(err error) (int, any)
 
 type Engine struct {
@@ -31,68 +61,183 @@ type Engine struct {
 	//日志
 	Logger *csLog.Logger
 	//中间件
-	middle       []MiddlewareFunc
+	middle       HandlersChain
 	errorHandler ErrorHandler
+	//找不到路由和请求方式不被允许时的处理方法，以及它们的中间件
+	noRoute         HandleFunc
+	noRouteMiddles  []MiddlewareFunc
+	noMethod        HandleFunc
+	noMethodMiddles []MiddlewareFunc
+	//编译好的处理链
+	noRouteChain  HandlersChain
+	noMethodChain HandlersChain
+	//OPTIONS请求没有单独注册时的处理链，经过引擎级别的中间件，比如CORS中间件可以处理预检请求
+	optionsChain HandlersChain
+	//找不到路由时，如果去掉或加上结尾的 / 能匹配到路由，重定向过去，默认开启
+	RedirectTrailingSlash bool
+	//找不到路由时，清理路径中多余的 / 以及 . 和 .. 后再匹配，匹配到就重定向过去
+	RedirectFixedPath bool
+	//找不到路由时，忽略大小写再匹配一次，匹配到就重定向到注册的路由
+	CaseInsensitive bool
+	//启动时打印所有注册的路由
+	PrintRoutes bool
+	//收到SIGHUP时启动新的进程接管监听器，当前进程处理完正在处理的请求后退出
+	HotRestart bool
+	//不使用TLS时也支持HTTP/2（h2c），用于内部服务之间的调用
+	H2C bool
+	//请求体的最大字节数，0表示不限制，路由上可以用 BodyLimit 单独设置
+	MaxBodyBytes int64
+	//解析multipart表单时最多使用的内存，超过的部分保存到临时文件，路由上可以用 MultipartMemory 单独设置
+	MaxMultipartMemory int64
+	//绑定参数时使用的验证器，为nil时使用全局的 binding.Validator，可以用 binding.NewValidator 创建
+	Validator binding.StructValidator
+	//退出时执行的方法
+	shutdownHooks []func() error
+	hooksOnce     sync.Once
+	//关闭后正在运行的服务器优雅退出
+	quit     chan struct{}
+	quitOnce sync.Once
 }
 
 //用组来维护uri映射和方法
 type routerGroup struct {
 	name string
+	//组的完整前缀，比如 /api/v1，嵌套的组在父组的前缀之后拼接自己的名字
+	prefix string
+	//父组，顶层的组为nil
+	parent *routerGroup
 	//一个路径对应一个请求回复处理方法
 	handleFuncMap     map[string]map[string]HandleFunc
 	middlewareFuncMap map[string]map[string][]MiddlewareFunc
 	handlerMethodMap  map[string][]string
-	treeNode          *treeNode
+	//用全部中间件包装好的处理方法，注册路由和添加中间件时编译，处理请求时直接执行
+	chainMap map[string]map[string]HandlersChain
+	engine   *Engine
 	//中间件
-	middlewares []MiddlewareFunc
+	middlewares HandlersChain
 }
 
 // 路由器-->组--> get(key) --> handler
 type router struct {
 	//一个路由维护路由组
 	routerGroups []*routerGroup
-	engine       *Engine
+	//所有组的路由都放在同一棵前缀树中
+	treeNode *treeNode
+	engine   *Engine
+}
+
+// routeMatch 一次路由匹配的结果，每个请求各自持有一份，匹配过程不修改树中的任何节点
+type routeMatch struct {
+	//完整的路由，比如 /user/get/:id
+	routerName string
+	//在组中注册时使用的名字，比如 /get/:id
+	name   string
+	group  *routerGroup
+	params Params
+}
+
+// 在前缀树中匹配请求路径，树在注册完成后只读，可以被多个请求并发匹配
+// 优先匹配注册了这种请求方式的路由，都没有时返回优先级最高的路由，由调用方返回405
+func (r *router) match(path string, method string) (routeMatch, bool) {
+	node, params := r.treeNode.GetFunc(path, func(n *treeNode) bool {
+		return n.group.hasHandler(n.name, method)
+	})
+	if node == nil {
+		node, params = r.treeNode.Get(path)
+	}
+	if node == nil {
+		return routeMatch{}, false
+	}
+	return routeMatch{
+		routerName: node.routerName,
+		name:       node.name,
+		group:      node.group,
+		params:     params,
+	}, true
 }
 
-// Group 创建一个组，并将其放到路由中
+// 请求路径能匹配到的所有路由支持的请求方式，用于405和OPTIONS响应中的Allow
+func (r *router) allowedMethods(path string) []string {
+	seen := make(map[string]bool)
+	var methods []string
+	//accept总是返回false，回溯过程会经过所有能匹配的路由
+	r.treeNode.GetFunc(path, func(n *treeNode) bool {
+		for _, method := range n.group.allowedMethods(n.name) {
+			if !seen[method] {
+				seen[method] = true
+				methods = append(methods, method)
+			}
+		}
+		return false
+	})
+	sort.Strings(methods)
+	return methods
+}
+
+// Group 创建一个组，并将其放到路由中，引擎的中间件对组中的路由生效
 func (r *router) Group(name string) *routerGroup {
+	return r.newGroup(nil, name)
+}
+
+// Group 在组中创建子组，子组的前缀拼接在父组之后，并且继承父组的中间件
+func (r *routerGroup) Group(name string) *routerGroup {
+	return r.engine.newGroup(r, name)
+}
+
+func (r *router) newGroup(parent *routerGroup, name string) *routerGroup {
+	prefix := ""
+	if parent != nil {
+		prefix = parent.prefix
+	}
 	routerGroup := &routerGroup{
 		name:              name,
+		prefix:            joinPaths(prefix, name),
+		parent:            parent,
 		handleFuncMap:     make(map[string]map[string]HandleFunc),
 		middlewareFuncMap: make(map[string]map[string][]MiddlewareFunc),
 		handlerMethodMap:  make(map[string][]string),
-		treeNode:          &treeNode{name: "/", children: make([]*treeNode, 0)},
+		chainMap:          make(map[string]map[string]HandlersChain),
+		engine:            r.engine,
 	}
-	//添加中间件
-	routerGroup.Use(r.engine.middle...)
 	//将路由组放到路由中
 	r.routerGroups = append(r.routerGroups, routerGroup)
 	return routerGroup
 }
 
+// Use 添加组的中间件，对组和子组中的所有路由生效，包括已经注册的路由
 func (r *routerGroup) Use(middlewareFunc ...MiddlewareFunc) {
-	r.middlewares = append(r.middlewares, middlewareFunc...)
+	r.middlewares = append(r.middlewares, wrapMiddlewares(middlewareFunc)...)
+	r.engine.compile()
+}
+
+// UseFunc 添加使用 ctx.Next()、ctx.Abort() 控制处理链的中间件，和Use添加的中间件一样后添加的先执行
+func (r *routerGroup) UseFunc(handlers ...HandleFunc) {
+	r.middlewares = append(r.middlewares, handlers...)
+	r.engine.compile()
 }
 
+// MethodHandle 用中间件包装处理方法后执行
 func (r *routerGroup) MethodHandle(name string, method string, h HandleFunc, ctx *Context) {
+	ctx.run(r.chain(name, method, h))
+}
 
-	//组全部处理中间件
-	if r.middlewares != nil {
-		for _, middleware := range r.middlewares {
-			h = middleware(h)
-		}
+// 组装处理链
+// 中间件按添加的顺序排列：引擎的中间件、父组的中间件、本组的中间件、路由级别的中间件，后添加的在外层先执行
+func (r *routerGroup) chain(name string, method string, h HandleFunc) HandlersChain {
+	var groups []*routerGroup
+	for group := r; group != nil; group = group.parent {
+		groups = append(groups, group)
 	}
-	//组路由级别中间件
-	middlewareFunc := r.middlewareFuncMap[name][method]
-	if middlewareFunc != nil {
-		for _, middlewareFunc1 := range middlewareFunc {
-			h = middlewareFunc1(h)
-		}
+	//组全部处理中间件，从顶层的组一直到本组
+	var handlers HandlersChain
+	for i := len(groups) - 1; i >= 0; i-- {
+		handlers = append(handlers, groups[i].middlewares...)
 	}
-	h(ctx)
+	//组路由级别中间件
+	handlers = append(handlers, wrapMiddlewares(r.middlewareFuncMap[name][method])...)
+	return r.engine.chain(h, handlers)
 }
 
-// 将路径和处理方法映射起来，同时把中间件和处理方法也映射上
 func (r *routerGroup) handle(name string, handleFunc HandleFunc, method string, middlewareFunc ...MiddlewareFunc) {
 	_, ok := r.handleFuncMap[name]
 	if !ok {
@@ -107,8 +252,56 @@ func (r *routerGroup) handle(name string, handleFunc HandleFunc, method string,
 	r.handlerMethodMap[method] = append(r.handlerMethodMap[method], name)
 
 	r.middlewareFuncMap[name][method] = append(r.middlewareFuncMap[name][method], middlewareFunc...)
+	if r.chainMap[name] == nil {
+		r.chainMap[name] = make(map[string]HandlersChain)
+	}
+	r.chainMap[name][method] = r.chain(name, method, handleFunc)
+
+	path := r.fullPath(name)
+	node := r.engine.treeNode.Put(path)
+	if node.group != nil && node.group != r {
+		panic(fmt.Sprintf("路由 %s 已经在其他组中注册", path))
+	}
+	node.group = r
+	node.name = name
+}
+
+// 路由是否有处理这种请求方式的方法，HEAD请求可以使用GET的处理方法
+func (r *routerGroup) hasHandler(name string, method string) bool {
+	handlers := r.chainMap[name]
+	if _, ok := handlers[ANY]; ok {
+		return true
+	}
+	if _, ok := handlers[method]; ok {
+		return true
+	}
+	_, ok := handlers[http.MethodGet]
+	return ok && method == http.MethodHead
+}
+
+// 路由支持的请求方式，注册了GET的同时支持HEAD，所有路由都支持OPTIONS
+func (r *routerGroup) allowedMethods(name string) []string {
+	methods := []string{http.MethodOptions}
+	for method := range r.handleFuncMap[name] {
+		if method != http.MethodOptions {
+			methods = append(methods, method)
+		}
+	}
+	_, hasGet := r.handleFuncMap[name][http.MethodGet]
+	_, hasHead := r.handleFuncMap[name][http.MethodHead]
+	if hasGet && !hasHead {
+		methods = append(methods, http.MethodHead)
+	}
+	sort.Strings(methods)
+	return methods
+}
 
-	r.treeNode.Put(name)
+// 组中的路由对应的完整路径，比如组user中的 /get/:id 对应 /user/get/:id
+func (r *routerGroup) fullPath(name string) string {
+	if name == "" || name[0] != '/' {
+		name = "/" + name
+	}
+	return r.prefix + name
 }
 
 func (r *routerGroup) Any(name string, handleFunc HandleFunc, middlewareFunc ...MiddlewareFunc) {
@@ -141,8 +334,16 @@ func (r *routerGroup) Head(name string, handlerFunc HandleFunc, middlewareFunc .
 // New 初始化启动引擎/**
 func New() *Engine {
 	engine := &Engine{
-		router: router{},
+		router:                router{treeNode: &treeNode{}},
+		noRoute:               defaultNoRoute,
+		noMethod:              defaultNoMethod,
+		RedirectTrailingSlash: true,
+		MaxBodyBytes:          int64(confInt(config.Conf.Server, "max_body_bytes", 0)),
+		MaxMultipartMemory:    int64(confInt(config.Conf.Server, "max_multipart_memory", defaultMaxMemory)),
+		quit:                  make(chan struct{}),
 	}
+	engine.router.engine = engine
+	engine.compile()
 	engine.pool.New = func() any {
 		return engine.allocateContext()
 	}
@@ -158,7 +359,6 @@ func Default() *Engine {
 	}
 	//默认对每个组都进行添加日志中间件和错误处理中间件
 	engine.Use(Logging, Recovery)
-	engine.router.engine = engine
 	return engine
 }
 
@@ -191,10 +391,11 @@ func (e *Engine) SetHtmlTemplate(t *template.Template) {
 //http通道的修饰，包装成ctx，并且添加了日志处理，和请求处理
 func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := e.pool.Get().(*Context)
-	ctx.W = w
-	ctx.R = r
+	ctx.reset(w, r)
 	ctx.Logger = e.Logger
-	e.httpRequestHandle(ctx, w, r)
+	e.httpRequestHandle(ctx, ctx.W, r)
+	//处理链只设置了状态码没有写入响应体时，在这里发送响应头
+	ctx.W.WriteHeaderNow()
 	e.pool.Put(ctx)
 }
 
@@ -205,15 +406,14 @@ func (e *Engine) Run() {
 	//		http.HandleFunc("/"+group.name+key, value)
 	//	}
 	//}
-	http.Handle("/", e)
 	//http服务器监听http请求在8111端口上
-	err := http.ListenAndServe(":8111", nil)
+	err := e.RunServer(":8111", nil)
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 func (e *Engine) RunTLS(addr, certFile, keyFile string) {
-	err := http.ListenAndServeTLS(addr, certFile, keyFile, e.Handler())
+	err := e.RunServerTLS(addr, &TLSOptions{CertFile: certFile, KeyFile: keyFile}, nil)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -224,39 +424,189 @@ func (e *Engine) httpRequestHandle(ctx *Context, w http.ResponseWriter, r *http.
 
 	method := r.Method
 
-	//处理 路径映射和方法请求方式
-	for _, group := range e.routerGroups {
-		//拿到请求的路径（不含参数）
-		routerName := SubStringLast(r.URL.Path, "/"+group.name)
-
-		node := group.treeNode.Get(routerName)
-		if node != nil && !node.isEnd {
-			//从group中拿到请求方法
+	//所有组的路由都在同一棵树中，匹配结果记录了注册它的组
+	m, ok := e.match(r.URL.Path, method)
+	if ok {
+		group := m.group
+		ctx.params = m.params
+		ctx.fullPath = m.routerName
+		//从group中拿到请求方法
 
-			handle, ok := group.handleFuncMap[node.routerName][ANY]
-			if ok {
-				//处理通道
-				group.MethodHandle(node.routerName, ANY, handle, ctx)
-				return
-			}
-			handle, ok = group.handleFuncMap[node.routerName][method]
+		handlers, ok := group.chainMap[m.name][ANY]
+		if ok {
+			//处理通道
+			ctx.run(handlers)
+			return
+		}
+		handlers, ok = group.chainMap[m.name][method]
+		if ok {
+			ctx.run(handlers)
+			return
+		}
+		//HEAD请求没有单独注册时使用GET的处理方法，响应体由http库丢弃
+		if method == http.MethodHead {
+			handlers, ok = group.chainMap[m.name][http.MethodGet]
 			if ok {
-				group.MethodHandle(node.routerName, method, handle, ctx)
+				ctx.run(handlers)
 				return
 			}
+		}
 
-			//对这种url请求的处理方式没有，返回状态码
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			fmt.Fprintf(w, "%s %s not allowed\n", r.RequestURI, method)
+		allow := strings.Join(e.allowedMethods(r.URL.Path), ", ")
+		w.Header().Set("Allow", allow)
+		//OPTIONS请求没有单独注册时，返回路由支持的请求方式
+		if method == http.MethodOptions {
+			ctx.run(e.optionsChain)
 			return
 		}
+		//其他请求方式的路由挡住了规范路由时，仍然重定向过去
+		if e.tryRedirect(ctx, r) {
+			return
+		}
+		//对这种url请求的处理方式没有，交给NoMethod处理
+		ctx.run(e.noMethodChain)
+		return
+	}
+	if e.tryRedirect(ctx, r) {
+		return
+	}
+	ctx.run(e.noRouteChain)
+}
+
+// 请求路径能重定向到支持该请求方式的规范路由时，执行重定向并返回true
+func (e *Engine) tryRedirect(ctx *Context, r *http.Request) bool {
+	if r.Method == http.MethodConnect {
+		return false
+	}
+	location, ok := e.redirectPath(r.URL.Path, r.Method)
+	if !ok {
+		return false
 	}
-	w.WriteHeader(http.StatusNotFound)
-	fmt.Fprintf(w, "%s not found\n", r.RequestURI)
+	//重定向的地址每次都不同，并且很少发生，所以临时组装处理链
+	ctx.run(e.chain(redirectHandle(location), nil))
+	return true
 }
 
+// 找到请求路径对应的规范路由，按 结尾的/、清理后的路径、忽略大小写 的顺序尝试
+// 只重定向到能处理该请求方式的路由，OPTIONS请求所有路由都能自动回复
+func (e *Engine) redirectPath(path string, method string) (string, bool) {
+	accept := func(n *treeNode) bool {
+		return method == http.MethodOptions || n.group.hasHandler(n.name, method)
+	}
+	var candidates []string
+	if e.RedirectTrailingSlash && path != "/" {
+		candidates = append(candidates, toggleTrailingSlash(path))
+	}
+	if e.RedirectFixedPath {
+		fixed := cleanPath(path)
+		if fixed != path {
+			candidates = append(candidates, fixed)
+			if e.RedirectTrailingSlash && fixed != "/" {
+				candidates = append(candidates, toggleTrailingSlash(fixed))
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		if node, _ := e.treeNode.GetFunc(candidate, accept); node != nil {
+			return candidate, true
+		}
+	}
+	if e.CaseInsensitive {
+		candidates = append([]string{path}, candidates...)
+		for _, candidate := range candidates {
+			if fixed, ok := e.treeNode.findCaseInsensitive(candidate, accept); ok && fixed != path {
+				return fixed, true
+			}
+		}
+	}
+	return "", false
+}
+
+// 重定向到规范的路由，保留查询参数，GET请求使用301，其他请求使用308保证请求方式和请求体不变
+func redirectHandle(path string) HandleFunc {
+	return func(ctx *Context) {
+		code := http.StatusMovedPermanently
+		if ctx.R.Method != http.MethodGet {
+			code = http.StatusPermanentRedirect
+		}
+		//避免 //host 和 /\host 形式的路径被当成其他站点，每一段都转义，\ 转义成 %5C
+		segments := strings.Split(strings.TrimLeft(path, "/"), "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		location := "/" + strings.Join(segments, "/")
+		if ctx.R.URL.RawQuery != "" {
+			location += "?" + ctx.R.URL.RawQuery
+		}
+		ctx.Redirect(code, location)
+	}
+}
+
+// 组装处理链，middlewares排在引擎的中间件之后，和它们一起按后添加的先执行的顺序放在h之前
+func (e *Engine) chain(h HandleFunc, middlewares HandlersChain) HandlersChain {
+	all := make(HandlersChain, 0, len(e.middle)+len(middlewares))
+	all = append(all, e.middle...)
+	all = append(all, middlewares...)
+	//和包装式的中间件一样，后添加的包在外层，所以倒序放入处理链
+	chain := make(HandlersChain, 0, len(all)+1)
+	for i := len(all) - 1; i >= 0; i-- {
+		chain = append(chain, all[i])
+	}
+	return append(chain, h)
+}
+
+// 重新编译所有的处理链，在添加中间件后调用，保证之前注册的路由也能使用新的中间件
+func (e *Engine) compile() {
+	for _, group := range e.routerGroups {
+		for name, handles := range group.handleFuncMap {
+			for method, handle := range handles {
+				group.chainMap[name][method] = group.chain(name, method, handle)
+			}
+		}
+	}
+	e.noRouteChain = e.chain(e.noRoute, wrapMiddlewares(e.noRouteMiddles))
+	e.noMethodChain = e.chain(e.noMethod, wrapMiddlewares(e.noMethodMiddles))
+	e.optionsChain = e.chain(defaultOptions, nil)
+}
+
+// NoRoute 设置找不到路由时的处理方法，和组中的路由一样经过引擎级别的中间件
+func (e *Engine) NoRoute(handleFunc HandleFunc, middlewareFunc ...MiddlewareFunc) {
+	e.noRoute = handleFunc
+	e.noRouteMiddles = middlewareFunc
+	e.noRouteChain = e.chain(e.noRoute, wrapMiddlewares(e.noRouteMiddles))
+}
+
+// NoMethod 设置路由存在但请求方式不被允许时的处理方法，执行时响应头中已经设置好了Allow
+func (e *Engine) NoMethod(handleFunc HandleFunc, middlewareFunc ...MiddlewareFunc) {
+	e.noMethod = handleFunc
+	e.noMethodMiddles = middlewareFunc
+	e.noMethodChain = e.chain(e.noMethod, wrapMiddlewares(e.noMethodMiddles))
+}
+
+// 响应头中已经设置好了Allow，只返回204
+func defaultOptions(ctx *Context) {
+	ctx.W.WriteHeader(http.StatusNoContent)
+}
+
+func defaultNoRoute(ctx *Context) {
+	ctx.String(http.StatusNotFound, "%s not found\n", ctx.R.RequestURI)
+}
+
+func defaultNoMethod(ctx *Context) {
+	ctx.String(http.StatusMethodNotAllowed, "%s %s not allowed\n", ctx.R.RequestURI, ctx.R.Method)
+}
+
+// Use 添加引擎级别的中间件，对所有组的路由以及NoRoute、NoMethod生效，包括之前创建的组
+// 引擎的中间件在组和路由的中间件里层执行，后添加的在外层
 func (e *Engine) Use(middles ...MiddlewareFunc) {
-	e.middle = append(e.middle, middles...)
+	e.middle = append(e.middle, wrapMiddlewares(middles)...)
+	e.compile()
+}
+
+// UseFunc 添加引擎级别的中间件，中间件使用 ctx.Next()、ctx.Abort() 控制处理链
+func (e *Engine) UseFunc(handlers ...HandleFunc) {
+	e.middle = append(e.middle, handlers...)
+	e.compile()
 }
 
 func (e *Engine) RegisterErrorHandler(handler ErrorHandler) {
@@ -0,0 +1,677 @@
+package csgo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"web/csgo/binding"
+	csLog "web/csgo/log"
+
+	"github.com/BurntSushi/toml"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gopkg.in/yaml.v3"
+)
+
+// 并发请求相互重叠的路由，配合 go test -race 检查匹配过程没有数据竞争
+func TestEngineConcurrentMatch(t *testing.T) {
+	engine := New()
+	group := engine.Group("user")
+	handle := func(ctx *Context) {
+		ctx.String(http.StatusOK, "%s|%s|%s", ctx.FullPath(), ctx.Param("id"), ctx.Param("**"))
+	}
+	group.Get("/profile", handle)
+	group.Get("/:id", handle)
+	group.Get("/:id/posts", handle)
+	group.Get("/**", handle)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/user/profile", "/user/profile||"},
+		{"/user/12", "/user/:id|12|"},
+		{"/user/profile/posts", "/user/:id/posts|profile|"},
+		{"/user/12/posts", "/user/:id/posts|12|"},
+		{"/user/12/a/b", "/user/**||12/a/b"},
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan string, 64)
+	for g := 0; g < 32; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				test := tests[(g+i)%len(tests)]
+				w := httptest.NewRecorder()
+				engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+				if got := w.Body.String(); got != test.want {
+					select {
+					case errs <- fmt.Sprintf("%s: got %q, want %q", test.path, got, test.want):
+					default:
+					}
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestNestedGroup(t *testing.T) {
+	engine := New()
+	trace := func(name string) MiddlewareFunc {
+		return func(next HandleFunc) HandleFunc {
+			return func(ctx *Context) {
+				ctx.W.Write([]byte(name + ">"))
+				next(ctx)
+			}
+		}
+	}
+	api := engine.Group("api")
+	api.Use(trace("api"))
+	v1 := api.Group("v1")
+	v1.Use(trace("v1"))
+	admin := v1.Group("/admin/")
+	admin.Use(trace("admin"))
+	admin.Get("/user/:id", func(ctx *Context) {
+		ctx.W.Write([]byte(ctx.Param("id")))
+	}, trace("route"))
+	engine.Group("user").Get("/list", func(ctx *Context) {
+		ctx.W.Write([]byte("list"))
+	})
+
+	tests := []struct {
+		path string
+		code int
+		want string
+	}{
+		//后添加的在外层：路由级别的中间件包在组的外面，子组包在父组的外面
+		{"/api/v1/admin/user/7", http.StatusOK, "route>admin>v1>api>7"},
+		{"/user/list", http.StatusOK, "list"},
+		//组的前缀只匹配路径的开头
+		{"/api/user/list", http.StatusNotFound, ""},
+		{"/v1/admin/user/7", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if w.Code != test.code {
+			t.Errorf("%s: code = %d, want %d", test.path, w.Code, test.code)
+		}
+		if test.code == http.StatusOK && w.Body.String() != test.want {
+			t.Errorf("%s: body = %q, want %q", test.path, w.Body.String(), test.want)
+		}
+	}
+}
+
+func TestAutoHeadAndOptions(t *testing.T) {
+	engine := New()
+	group := engine.Group("user")
+	group.Get("/info", func(ctx *Context) {
+		ctx.String(http.StatusOK, "info")
+	})
+	group.Post("/info", func(ctx *Context) {})
+	group.Delete("/:id", func(ctx *Context) {})
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		allow  string
+	}{
+		{http.MethodHead, "/user/info", http.StatusOK, ""},
+		//DELETE /user/info 由 /:id 处理，所以也在Allow中
+		{http.MethodOptions, "/user/info", http.StatusNoContent, "DELETE, GET, HEAD, OPTIONS, POST"},
+		{http.MethodPut, "/user/info", http.StatusMethodNotAllowed, "DELETE, GET, HEAD, OPTIONS, POST"},
+		{http.MethodGet, "/user/1", http.StatusMethodNotAllowed, "DELETE, OPTIONS"},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.code {
+			t.Errorf("%s %s: code = %d, want %d", test.method, test.path, w.Code, test.code)
+		}
+		if allow := w.Header().Get("Allow"); allow != test.allow {
+			t.Errorf("%s %s: Allow = %q, want %q", test.method, test.path, allow, test.allow)
+		}
+	}
+
+	//自动的OPTIONS响应也经过引擎的中间件，CORS中间件可以直接处理预检请求
+	var called []string
+	engine.Use(func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			called = append(called, ctx.R.Method)
+			if ctx.R.Method == http.MethodOptions {
+				ctx.W.Header().Set("Access-Control-Allow-Origin", "*")
+			}
+			next(ctx)
+		}
+	})
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/user/info", nil))
+	if len(called) != 1 || w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("OPTIONS through middleware: called %v, got %d %v", called, w.Code, w.Header())
+	}
+}
+
+func TestNoRouteAndNoMethod(t *testing.T) {
+	engine := New()
+	var logged []string
+	engine.Use(func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			next(ctx)
+			logged = append(logged, fmt.Sprintf("%s %d", ctx.R.URL.Path, ctx.StatusCode))
+		}
+	})
+	engine.Group("user").Get("/info", func(ctx *Context) {})
+	engine.NoRoute(func(ctx *Context) {
+		ctx.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+	})
+	engine.NoMethod(func(ctx *Context) {
+		ctx.JSON(http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != `{"error":"not found"}` {
+		t.Errorf("no route: %d %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/user/info", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Errorf("no method: %d %s", w.Code, w.Header().Get("Allow"))
+	}
+	want := []string{"/missing 404", "/user/info 405"}
+	if fmt.Sprint(logged) != fmt.Sprint(want) {
+		t.Errorf("logged = %v, want %v", logged, want)
+	}
+}
+
+func TestRedirectToCanonicalPath(t *testing.T) {
+	engine := New()
+	engine.RedirectFixedPath = true
+	engine.CaseInsensitive = true
+	group := engine.Group("user")
+	group.Get("/list", func(ctx *Context) {})
+	group.Post("/:id/Info/", func(ctx *Context) {})
+
+	tests := []struct {
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{http.MethodGet, "/user/list/", http.StatusMovedPermanently, "/user/list"},
+		{http.MethodGet, "/user/list/?page=2", http.StatusMovedPermanently, "/user/list?page=2"},
+		{http.MethodPost, "/user/7/Info", http.StatusPermanentRedirect, "/user/7/Info/"},
+		{http.MethodGet, "//user/./x/../list", http.StatusMovedPermanently, "/user/list"},
+		{http.MethodGet, "/USER/LIST", http.StatusMovedPermanently, "/user/list"},
+		{http.MethodPost, "/User/AbC/info/", http.StatusPermanentRedirect, "/user/AbC/Info/"},
+		{http.MethodGet, "/order/list", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.code {
+			t.Errorf("%s %s: code = %d, want %d", test.method, test.path, w.Code, test.code)
+		}
+		if location := w.Header().Get("Location"); location != test.location {
+			t.Errorf("%s %s: Location = %q, want %q", test.method, test.path, location, test.location)
+		}
+	}
+
+	engine.RedirectTrailingSlash = false
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/list/", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("trailing slash disabled: code = %d, want 404", w.Code)
+	}
+
+	//其他请求方式的路由能匹配请求路径时，仍然重定向到支持该请求方式的规范路由
+	engine = New()
+	engine.RedirectFixedPath = true
+	engine.CaseInsensitive = true
+	group = engine.Group("user")
+	group.Get("/profile", func(ctx *Context) {})
+	group.Put("/:id", func(ctx *Context) {})
+	group.Post("/**", func(ctx *Context) {})
+	for _, test := range []struct {
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{http.MethodGet, "/user/Profile", http.StatusMovedPermanently, "/user/profile"},
+		{http.MethodGet, "/user//profile", http.StatusMovedPermanently, "/user/profile"},
+		{http.MethodPut, "/user/Profile", http.StatusOK, ""},
+		{http.MethodDelete, "/user/Profile", http.StatusMethodNotAllowed, ""},
+	} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.code || w.Header().Get("Location") != test.location {
+			t.Errorf("%s %s: got %d %q, want %d %q", test.method, test.path, w.Code, w.Header().Get("Location"), test.code, test.location)
+		}
+	}
+
+	//重定向的地址不能被浏览器当成其他站点，比如 /\evil.com 会被当成 //evil.com
+	engine = New()
+	engine.Group("").Get("/:name", func(ctx *Context) {})
+	for path, location := range map[string]string{
+		"/\\evil.com/": "/%5Cevil.com",
+		"/a%20b/":      "/a%20b",
+	} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != location {
+			t.Errorf("%s: got %d %q, want %q", path, w.Code, w.Header().Get("Location"), location)
+		}
+	}
+}
+
+func userInfo(ctx *Context) {}
+
+func TestRoutes(t *testing.T) {
+	engine := New()
+	noop := func(next HandleFunc) HandleFunc { return next }
+	api := engine.Group("api")
+	api.Use(noop)
+	v1 := api.Group("v1")
+	v1.Use(noop)
+	v1.Get("/user/:id", userInfo, noop)
+	v1.Post("/user", userInfo)
+	engine.Group("").Any("/ping", userInfo)
+
+	want := []RouteInfo{
+		{Method: http.MethodPost, Path: "/api/v1/user", Handler: "web/csgo.userInfo", Middlewares: 2},
+		{Method: http.MethodGet, Path: "/api/v1/user/:id", Handler: "web/csgo.userInfo", Middlewares: 3},
+		{Method: ANY, Path: "/ping", Handler: "web/csgo.userInfo", Middlewares: 0},
+	}
+	got := engine.Routes()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Routes() = %v, want %v", got, want)
+	}
+}
+
+func TestTypedParam(t *testing.T) {
+	engine := New()
+	engine.Group("user").Get("/:id<int>/:admin<bool>", func(ctx *Context) {
+		ctx.String(http.StatusOK, "%d %v", ctx.ParamInt64("id")+1, ctx.ParamBool("admin"))
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/41/true", nil))
+	if w.Body.String() != "42 true" {
+		t.Errorf("body = %q, want %q", w.Body.String(), "42 true")
+	}
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/abc/true", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("code = %d, want 404", w.Code)
+	}
+}
+
+func TestEngineUseAfterGroup(t *testing.T) {
+	engine := New()
+	group := engine.Group("user")
+	group.Get("/info", func(ctx *Context) {
+		ctx.W.Write([]byte("info"))
+	})
+	engine.Use(func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			ctx.W.Write([]byte("engine>"))
+			next(ctx)
+		}
+	})
+	group.Use(func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			ctx.W.Write([]byte("group>"))
+			next(ctx)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/info", nil))
+	//引擎的中间件在组的中间件里层
+	if w.Body.String() != "group>engine>info" {
+		t.Errorf("body = %q, want %q", w.Body.String(), "group>engine>info")
+	}
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if w.Body.String() != "engine>/missing not found\n" {
+		t.Errorf("body = %q", w.Body.String())
+	}
+}
+
+func TestAbortChain(t *testing.T) {
+	engine := New()
+	var aborted []bool
+	//原有的包装写法，不调用next即中止
+	engine.Use(func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			if ctx.R.Header.Get("Authorization") == "" {
+				ctx.W.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next(ctx)
+		}
+	})
+	group := engine.Group("user")
+	group.UseFunc(func(ctx *Context) {
+		if ctx.Param("id") == "0" {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		}
+	})
+	//新的中间件写法，在Next之后检查处理链是否被中止，最后添加的在最外层
+	group.UseFunc(func(ctx *Context) {
+		ctx.Next()
+		aborted = append(aborted, ctx.IsAborted())
+	})
+	group.Get("/:id", func(ctx *Context) {
+		ctx.String(http.StatusOK, "user %s", ctx.Param("id"))
+	})
+
+	tests := []struct {
+		id    string
+		auth  bool
+		code  int
+		body  string
+		abort bool
+	}{
+		{"1", false, http.StatusUnauthorized, "", true},
+		{"0", true, http.StatusForbidden, `{"error":"forbidden"}`, true},
+		{"1", true, http.StatusOK, "user 1", false},
+	}
+	for _, test := range tests {
+		aborted = aborted[:0]
+		r := httptest.NewRequest(http.MethodGet, "/user/"+test.id, nil)
+		if test.auth {
+			r.Header.Set("Authorization", "Basic xxx")
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("%s: got %d %q, want %d %q", test.id, w.Code, w.Body.String(), test.code, test.body)
+		}
+		if len(aborted) != 1 || aborted[0] != test.abort {
+			t.Errorf("%s: aborted = %v, want %v", test.id, aborted, test.abort)
+		}
+	}
+}
+
+func TestRecoveryStopsChain(t *testing.T) {
+	engine := New()
+	engine.Logger = csLog.Default()
+	after := false
+	group := engine.Group("user")
+	//后添加的先执行，依次是Recovery、panic的中间件、设置after的中间件
+	group.UseFunc(func(ctx *Context) {
+		after = true
+	}, func(ctx *Context) {
+		panic(errors.New("boom"))
+	})
+	group.Use(Recovery)
+	group.Get("/info", func(ctx *Context) {
+		after = true
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/user/info", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("code = %d, want 500", w.Code)
+	}
+	if after {
+		t.Error("handlers after the panic were executed")
+	}
+}
+
+func TestBindByContentType(t *testing.T) {
+	engine := New()
+	type user struct {
+		Name string `json:"name" xml:"name" form:"name" yaml:"name"`
+	}
+	engine.Group("").Any("/user", func(ctx *Context) {
+		var u user
+		if err := ctx.Bind(&u); err != nil {
+			return
+		}
+		ctx.String(http.StatusOK, u.Name)
+	})
+
+	tests := []struct {
+		method      string
+		target      string
+		contentType string
+		body        string
+		code        int
+		want        string
+	}{
+		{http.MethodGet, "/user?name=query", "", "", http.StatusOK, "query"},
+		{http.MethodPost, "/user", "application/json; charset=utf-8", `{"name":"json"}`, http.StatusOK, "json"},
+		{http.MethodPost, "/user", "application/xml", `<user><name>xml</name></user>`, http.StatusOK, "xml"},
+		{http.MethodPost, "/user", "application/x-www-form-urlencoded", "name=form", http.StatusOK, "form"},
+		{http.MethodPut, "/user", "application/x-yaml", "name: yaml", http.StatusOK, "yaml"},
+		{http.MethodPost, "/user", "image/png", "png", http.StatusUnsupportedMediaType, "unsupported media type: image/png\n"},
+		{http.MethodPost, "/user", "application/json", `{"name":`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			r.Header.Set("Content-Type", tt.contentType)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		if w.Code != tt.code || w.Body.String() != tt.want {
+			t.Errorf("%s %s: got %d %q", tt.method, tt.contentType, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestBindJsonContextOptions(t *testing.T) {
+	engine := New()
+	type user struct {
+		Name string `json:"name" csgo:"required"`
+	}
+	engine.Group("").Post("/user", func(ctx *Context) {
+		ctx.DisallowUnknownFields = ctx.GetDefaultQuery("strict", "") == "1"
+		ctx.IsValidate = true
+		var u user
+		if err := ctx.BindJson(&u); err != nil {
+			ctx.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		ctx.String(http.StatusOK, u.Name)
+	})
+
+	tests := []struct {
+		target string
+		body   string
+		code   int
+	}{
+		{"/user", `{"name":"cs","age":18}`, http.StatusOK},
+		{"/user?strict=1", `{"name":"cs","age":18}`, http.StatusBadRequest},
+		{"/user", `{"age":18}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body)))
+		if w.Code != tt.code {
+			t.Errorf("%s %s: got %d %q", tt.target, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestValidationProblem(t *testing.T) {
+	engine := New()
+	type user struct {
+		Name string `json:"name" validate:"required"`
+		Age  int    `json:"age" validate:"gte=18"`
+	}
+	engine.Group("").Post("/user", func(ctx *Context) {
+		var u user
+		if err := ctx.BindJson(&u); err != nil {
+			ctx.ValidationProblem(err)
+		}
+	})
+
+	tests := []struct {
+		body     string
+		language string
+		code     int
+		want     string
+	}{
+		{`{"age":17}`, "zh-CN,zh;q=0.9,en;q=0.8", http.StatusUnprocessableEntity,
+			`{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"name: name为必填字段; age: age必须大于或等于18","instance":"/user","errors":[{"field":"name","rule":"required","message":"name为必填字段"},{"field":"age","rule":"gte","param":"18","message":"age必须大于或等于18"}]}`},
+		{`{"age":17}`, "", http.StatusUnprocessableEntity,
+			`{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"name: name is a required field; age: age must be 18 or greater","instance":"/user","errors":[{"field":"name","rule":"required","message":"name is a required field"},{"field":"age","rule":"gte","param":"18","message":"age must be 18 or greater"}]}`},
+		{`{"age":`, "", http.StatusBadRequest,
+			`{"type":"about:blank","title":"Bad Request","status":400,"detail":"unexpected EOF","instance":"/user"}`},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(tt.body))
+		r.Header.Set("Accept-Language", tt.language)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		if w.Code != tt.code || w.Body.String() != tt.want {
+			t.Errorf("%s %s: got %d %s", tt.body, tt.language, w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/problem+json; charset=utf-8" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+	}
+}
+
+func TestEngineValidator(t *testing.T) {
+	engine := New()
+	v := binding.NewValidator()
+	if err := binding.RegisterRule(v, "upper", func(s string, _ string) bool { return s == strings.ToUpper(s) }); err != nil {
+		t.Fatal(err)
+	}
+	engine.Validator = v
+	type code struct {
+		Code string `json:"code" uri:"code" validate:"upper"`
+	}
+	g := engine.Group("")
+	g.Post("/code", func(ctx *Context) {
+		var c code
+		if err := ctx.BindJson(&c); err != nil {
+			ctx.ValidationProblem(err)
+			return
+		}
+		ctx.String(http.StatusOK, c.Code)
+	})
+	g.Get("/code/:code", func(ctx *Context) {
+		var c code
+		if err := ctx.ShouldBindUri(&c); err != nil {
+			ctx.ValidationProblem(err)
+			return
+		}
+		ctx.String(http.StatusOK, c.Code)
+	})
+
+	tests := []struct {
+		method, path, body string
+		code               int
+	}{
+		{http.MethodPost, "/code", `{"code":"AB"}`, http.StatusOK},
+		{http.MethodPost, "/code", `{"code":"ab"}`, http.StatusUnprocessableEntity},
+		{http.MethodGet, "/code/AB", "", http.StatusOK},
+		{http.MethodGet, "/code/ab", "", http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if w.Code != tt.code {
+			t.Errorf("%s %s %s: got %d %s", tt.method, tt.path, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	type user struct {
+		Name string   `json:"name" yaml:"name" toml:"name"`
+		Tags []string `json:"tags" yaml:"tags" toml:"tags"`
+	}
+	engine := New()
+	engine.Group("").Post("/echo", func(ctx *Context) {
+		var u user
+		if err := ctx.Bind(&u); err != nil {
+			return
+		}
+		ctx.Negotiate(http.StatusOK, u, MIMEYAML, MIMETOML, MIMEMSGPACK)
+	})
+	engine.Group("").Post("/proto", func(ctx *Context) {
+		var msg wrapperspb.StringValue
+		if err := ctx.BindProtoBuf(&msg); err != nil {
+			return
+		}
+		ctx.ProtoBuf(http.StatusOK, wrapperspb.String(strings.ToUpper(msg.GetValue())))
+	})
+
+	want := user{Name: "cs", Tags: []string{"a", "b"}}
+	formats := []struct {
+		contentType string
+		marshal     func(any) ([]byte, error)
+		unmarshal   func([]byte, any) error
+	}{
+		{MIMEYAML, yaml.Marshal, yaml.Unmarshal},
+		{MIMETOML, func(v any) ([]byte, error) {
+			var buf bytes.Buffer
+			err := toml.NewEncoder(&buf).Encode(v)
+			return buf.Bytes(), err
+		}, toml.Unmarshal},
+		{MIMEMSGPACK, func(v any) ([]byte, error) {
+			var buf bytes.Buffer
+			enc := msgpack.NewEncoder(&buf)
+			enc.SetCustomStructTag("json")
+			err := enc.Encode(v)
+			return buf.Bytes(), err
+		}, func(data []byte, v any) error {
+			dec := msgpack.NewDecoder(bytes.NewReader(data))
+			dec.SetCustomStructTag("json")
+			return dec.Decode(v)
+		}},
+	}
+	for _, f := range formats {
+		body, err := f.marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader(body))
+		r.Header.Set("Content-Type", f.contentType)
+		r.Header.Set("Accept", f.contentType)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, f.contentType) {
+			t.Errorf("%s: Content-Type = %q", f.contentType, ct)
+		}
+		var got user
+		if err := f.unmarshal(w.Body.Bytes(), &got); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %d %+v %v", f.contentType, w.Code, got, err)
+		}
+	}
+
+	body, _ := proto.Marshal(wrapperspb.String("cs"))
+	r := httptest.NewRequest(http.MethodPost, "/proto", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	var msg wrapperspb.StringValue
+	if err := proto.Unmarshal(w.Body.Bytes(), &msg); err != nil || msg.GetValue() != "CS" {
+		t.Errorf("protobuf: got %q %v", msg.GetValue(), err)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != MIMEPROTOBUF {
+		t.Errorf("protobuf: Content-Type = %q", ct)
+	}
+}
@@ -3,10 +3,20 @@ module web/csgo
 go 1.18
 
 require (
-	github.com/BurntSushi/toml v1.2.1 // indirect
-	github.com/go-playground/locales v0.14.0 // indirect
-	github.com/go-playground/universal-translator v0.18.0 // indirect
-	github.com/go-playground/validator v9.31.0+incompatible // indirect
-	github.com/golang-jwt/jwt/v4 v4.4.3 // indirect
+	github.com/BurntSushi/toml v1.2.1
+	github.com/go-playground/locales v0.14.0
+	github.com/go-playground/universal-translator v0.18.0
+	github.com/golang-jwt/jwt/v4 v4.4.3
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	golang.org/x/net v0.23.0
+	google.golang.org/protobuf v1.28.1
+	gopkg.in/go-playground/validator.v9 v9.31.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
 	github.com/leodido/go-urn v1.2.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
 )
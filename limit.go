@@ -0,0 +1,88 @@
+package csgo
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ErrBodyTooLarge 请求体超过了 MaxBodyBytes 或者 BodyLimit 设置的大小
+var ErrBodyTooLarge = errors.New("http: request body too large")
+
+// 限制大小的请求体，超过限制时记录到ctx上，读取时返回 ErrBodyTooLarge
+// 底层使用 http.MaxBytesReader，超过限制后服务器会在响应后关闭连接，所以传给它的是原始的ResponseWriter
+type limitedBody struct {
+	rc    io.ReadCloser
+	ctx   *Context
+	limit int64
+	read  int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.ctx.bodyTooLarge {
+		return 0, ErrBodyTooLarge
+	}
+	//Content-Length已经超过限制时不需要读取
+	if b.read == 0 && b.ctx.R.ContentLength > b.limit {
+		b.ctx.bodyTooLarge = true
+		return 0, ErrBodyTooLarge
+	}
+	n, err := b.rc.Read(p)
+	b.read += int64(n)
+	//MaxBytesReader最多返回limit个字节，之后还有数据时返回错误
+	if err != nil && err != io.EOF && b.read >= b.limit {
+		b.ctx.bodyTooLarge = true
+		return n, ErrBodyTooLarge
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.rc.Close()
+}
+
+// 限制请求体的大小，limit小于等于0时不限制，只在读取请求体之前调用才有效
+// 每次都基于原始的请求体重新设置，路由上的限制可以比引擎的限制大
+func (c *Context) limitBody(limit int64) {
+	if c.rawBody == nil {
+		return
+	}
+	if limit <= 0 {
+		c.R.Body = c.rawBody
+		return
+	}
+	c.R.Body = &limitedBody{rc: http.MaxBytesReader(c.writermem.ResponseWriter, c.rawBody, limit), ctx: c, limit: limit}
+}
+
+// 请求体超过限制时返回413并中止处理链
+func (c *Context) abortBodyTooLarge() {
+	if c.IsAborted() {
+		return
+	}
+	c.abortWithError(http.StatusRequestEntityTooLarge, ErrBodyTooLarge)
+}
+
+// BodyLimit 设置路由的请求体大小限制，替换引擎的 MaxBodyBytes
+// Content-Length超过限制时直接返回413，不再执行后续的处理方法
+func BodyLimit(maxBytes int64) MiddlewareFunc {
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			if maxBytes > 0 && ctx.R.ContentLength > maxBytes {
+				ctx.abortBodyTooLarge()
+				return
+			}
+			ctx.limitBody(maxBytes)
+			next(ctx)
+		}
+	}
+}
+
+// MultipartMemory 设置路由解析multipart表单时最多使用的内存，超过的部分保存到临时文件
+func MultipartMemory(maxMemory int64) MiddlewareFunc {
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			ctx.maxMultipartMemory = maxMemory
+			next(ctx)
+		}
+	}
+}
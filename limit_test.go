@@ -0,0 +1,93 @@
+package csgo
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 隐藏长度的请求体，模拟chunked请求
+type unknownLength struct {
+	io.Reader
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	engine := New()
+	engine.MaxBodyBytes = 16
+	type user struct {
+		Name string `json:"name"`
+	}
+	group := engine.Group("")
+	group.Post("/json", func(ctx *Context) {
+		var u user
+		if err := ctx.BindJson(&u); err != nil {
+			return
+		}
+		ctx.String(http.StatusOK, u.Name)
+	})
+	group.Post("/form", func(ctx *Context) {
+		name, _ := ctx.GetPostForm("name")
+		if ctx.IsAborted() {
+			return
+		}
+		ctx.String(http.StatusOK, name)
+	})
+	group.Post("/upload", func(ctx *Context) {
+		body, _ := io.ReadAll(ctx.R.Body)
+		ctx.String(http.StatusOK, "%d", len(body))
+	}, BodyLimit(1024))
+
+	long := `{"name":"` + strings.Repeat("a", 32) + `"}`
+	tests := []struct {
+		path        string
+		contentType string
+		body        io.Reader
+		code        int
+		want        string
+	}{
+		{"/json", "application/json", strings.NewReader(`{"name":"cs"}`), http.StatusOK, "cs"},
+		{"/json", "application/json", strings.NewReader(long), http.StatusRequestEntityTooLarge, ""},
+		{"/json", "application/json", unknownLength{strings.NewReader(long)}, http.StatusRequestEntityTooLarge, ""},
+		{"/form", "application/x-www-form-urlencoded", strings.NewReader("name=cs"), http.StatusOK, "cs"},
+		{"/form", "application/x-www-form-urlencoded", unknownLength{strings.NewReader("name=" + strings.Repeat("a", 32))}, http.StatusRequestEntityTooLarge, ""},
+		//路由上的限制替换引擎的限制
+		{"/upload", "text/plain", strings.NewReader(strings.Repeat("a", 100)), http.StatusOK, "100"},
+		{"/upload", "text/plain", strings.NewReader(strings.Repeat("a", 2000)), http.StatusRequestEntityTooLarge, ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, tt.path, tt.body)
+		r.Header.Set("Content-Type", tt.contentType)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		if w.Code != tt.code {
+			t.Errorf("%s: code = %d, want %d", tt.path, w.Code, tt.code)
+		}
+		if tt.want != "" && w.Body.String() != tt.want {
+			t.Errorf("%s: body = %q, want %q", tt.path, w.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestBodyTooLargeErrorHandler(t *testing.T) {
+	engine := New()
+	engine.MaxBodyBytes = 4
+	engine.RegisterErrorHandler(func(err error) (int, any) {
+		if err == ErrBodyTooLarge {
+			return http.StatusRequestEntityTooLarge, map[string]string{"error": "too large"}
+		}
+		return http.StatusInternalServerError, nil
+	})
+	engine.Group("").Post("/json", func(ctx *Context) {
+		var obj map[string]any
+		_ = ctx.BindJson(&obj)
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/json", strings.NewReader(`{"name":"cs"}`))
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge || w.Body.String() != `{"error":"too large"}` {
+		t.Errorf("got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,46 @@
+package csgo
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// systemd 等进程管理器传递的第一个监听器的文件描述符
+const listenFdsStart = 3
+
+// RunListener 在已经打开的监听器上提供服务，比如测试中使用的 127.0.0.1:0
+// 热重启启动的新进程使用父进程传递的监听器，l会被关闭
+func (e *Engine) RunListener(l net.Listener) error {
+	listeners, err := listenOrInherit(func() ([]net.Listener, error) {
+		return []net.Listener{l}, nil
+	})
+	if err != nil {
+		return err
+	}
+	if listeners[0] != l {
+		l.Close()
+	}
+	return e.serveAll(listeners, nil, nil)
+}
+
+// RunUnix 在Unix域套接字上提供服务，path上残留的套接字文件会被先删除
+func (e *Engine) RunUnix(path string) error {
+	//热重启时由父进程传递监听器，套接字文件还在使用中
+	listeners, err := listenOrInherit(func() ([]net.Listener, error) {
+		if info, err := os.Stat(path); err == nil {
+			if info.Mode()&os.ModeSocket == 0 {
+				return nil, fmt.Errorf("%s 已存在并且不是套接字文件", path)
+			}
+			if err := os.Remove(path); err != nil {
+				return nil, err
+			}
+		}
+		return listenOne("unix", path)()
+	})
+	if err != nil {
+		return err
+	}
+	//监听器关闭时会删除套接字文件
+	return e.serveAll(listeners, nil, nil)
+}
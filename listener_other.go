@@ -0,0 +1,30 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris)
+
+package csgo
+
+import (
+	"errors"
+	"net"
+)
+
+// errFdUnsupported 当前系统不支持通过文件描述符继承监听器
+var errFdUnsupported = errors.New("当前系统不支持继承文件描述符")
+
+// RunFd 当前系统不支持，返回错误
+func (e *Engine) RunFd(fd int) error {
+	return errFdUnsupported
+}
+
+// RunActivated 当前系统不支持，返回错误
+func (e *Engine) RunActivated() error {
+	return errFdUnsupported
+}
+
+// ActivationListeners 当前系统不支持，返回错误
+func ActivationListeners() ([]net.Listener, error) {
+	return nil, errFdUnsupported
+}
+
+func fdListeners(start int, n int, names []string) ([]net.Listener, error) {
+	return nil, errFdUnsupported
+}
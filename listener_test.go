@@ -0,0 +1,81 @@
+package csgo
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newPingEngine() *Engine {
+	engine := New()
+	engine.Group("").Get("/ping", func(ctx *Context) {
+		ctx.String(http.StatusOK, "pong")
+	})
+	return engine
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestRunListener(t *testing.T) {
+	engine := newPingEngine()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.RunListener(l)
+	}()
+	if body := get(t, http.DefaultClient, "http://"+l.Addr().String()+"/ping"); body != "pong" {
+		t.Errorf("body = %q, want pong", body)
+	}
+	engine.Shutdown()
+	if err := <-done; err != nil {
+		t.Errorf("RunListener returned %v", err)
+	}
+}
+
+func TestRunUnix(t *testing.T) {
+	engine := newPingEngine()
+	path := filepath.Join(t.TempDir(), "csgo.sock")
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.RunUnix(path)
+	}()
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			var d net.Dialer
+			for {
+				conn, err := d.DialContext(ctx, "unix", path)
+				if err == nil || ctx.Err() != nil {
+					return conn, err
+				}
+				//等待服务器创建套接字文件
+				time.Sleep(10 * time.Millisecond)
+			}
+		},
+	}}
+	if body := get(t, client, "http://unix/ping"); body != "pong" {
+		t.Errorf("body = %q, want pong", body)
+	}
+	engine.Shutdown()
+	if err := <-done; err != nil {
+		t.Errorf("RunUnix returned %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket file not removed: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package csgo
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// RunFd 在继承的文件描述符上提供服务，文件描述符必须是已经在监听的套接字
+// 热重启启动的新进程使用父进程传递的监听器，不再使用fd
+func (e *Engine) RunFd(fd int) error {
+	listeners, err := listenOrInherit(func() ([]net.Listener, error) {
+		f := os.NewFile(uintptr(fd), "fd"+strconv.Itoa(fd))
+		if f == nil {
+			return nil, fmt.Errorf("文件描述符 %d 不可用", fd)
+		}
+		defer f.Close()
+		l, err := net.FileListener(f)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	})
+	if err != nil {
+		return err
+	}
+	return e.serveAll(listeners, nil, nil)
+}
+
+// RunActivated 在进程管理器（比如systemd的socket activation）传递的所有监听器上提供服务
+// 热重启启动的新进程中 LISTEN_FDS 已经被父进程清除，使用父进程传递的监听器
+func (e *Engine) RunActivated() error {
+	listeners, err := listenOrInherit(func() ([]net.Listener, error) {
+		listeners, err := ActivationListeners()
+		if err == nil && len(listeners) == 0 {
+			err = errors.New("没有继承的监听器，LISTEN_FDS 未设置")
+		}
+		return listeners, err
+	})
+	if err != nil {
+		return err
+	}
+	return e.serveAll(listeners, nil, nil)
+}
+
+// ActivationListeners 按照 LISTEN_PID、LISTEN_FDS 环境变量获得继承的监听器
+// 环境变量不是传给当前进程的时候返回空，读取后清除环境变量，避免子进程再次使用
+func ActivationListeners() ([]net.Listener, error) {
+	return activationListeners(listenFdsStart)
+}
+
+func activationListeners(start int) ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	return fdListeners(start, n, names)
+}
+
+// 把从start开始的n个文件描述符转换为监听器
+func fdListeners(start int, n int, names []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, n)
+	for fd := start; fd < start+n; fd++ {
+		syscall.CloseOnExec(fd)
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i := fd - start; i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(fd), name)
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("继承的文件描述符 %d 不是监听器: %w", fd, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
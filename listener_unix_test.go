@@ -0,0 +1,63 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package csgo
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestActivationListeners(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	f, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	//复制出一个只属于继承逻辑的文件描述符
+	fd, err := syscall.Dup(int(f.Fd()))
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", "http")
+	listeners, err := activationListeners(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listeners) != 1 || listeners[0].Addr().String() != l.Addr().String() {
+		t.Fatalf("listeners = %v", listeners)
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Error("LISTEN_FDS should be unset")
+	}
+
+	engine := newPingEngine()
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.serveAll(listeners, nil, nil)
+	}()
+	if body := get(t, http.DefaultClient, "http://"+l.Addr().String()+"/ping"); body != "pong" {
+		t.Errorf("body = %q, want pong", body)
+	}
+	engine.Shutdown()
+	if err := <-done; err != nil {
+		t.Errorf("serve returned %v", err)
+	}
+
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+	if listeners, _ := activationListeners(fd); listeners != nil {
+		t.Error("listeners for another process should be ignored")
+	}
+}
@@ -120,7 +120,7 @@ func LoggingWithConfig(conf LoggingConfig, next HandleFunc) HandleFunc {
 		ip, _, _ := net.SplitHostPort(strings.TrimSpace(ctx.R.RemoteAddr))
 		clientIP := net.ParseIP(ip)
 		method := ctx.R.Method
-		statusCode := ctx.StatusCode
+		statusCode := ctx.W.Status()
 
 		if raw != "" {
 			path = path + "?" + raw
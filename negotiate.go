@@ -0,0 +1,210 @@
+package csgo
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"web/csgo/binding"
+	"web/csgo/render"
+)
+
+// 内容协商支持的格式
+const (
+	MIMEJSON  = binding.MIMEJSON
+	MIMEXML   = binding.MIMEXML
+	MIMEXML2  = binding.MIMEXML2
+	MIMEHTML  = binding.MIMEHTML
+	MIMEPlain = binding.MIMEPlain
+	//下面的格式需要在offers中指定
+	MIMEYAML     = binding.MIMEYAML2
+	MIMETOML     = binding.MIMETOML
+	MIMEMSGPACK  = binding.MIMEMSGPACK2
+	MIMEPROTOBUF = binding.MIMEPROTOBUF
+)
+
+// ErrNotAcceptable Accept中没有服务端能提供的格式
+var ErrNotAcceptable = errors.New("not acceptable")
+
+// ErrUnsupportedOffer offers中有 Negotiate 不能渲染的格式
+var ErrUnsupportedOffer = errors.New("unsupported negotiation offer")
+
+// Negotiate 能渲染的格式
+var negotiableOffers = map[string]bool{
+	MIMEJSON: true, MIMEXML: true, MIMEXML2: true, MIMEHTML: true, MIMEPlain: true,
+	MIMEYAML: true, binding.MIMEYAML: true, MIMETOML: true,
+	MIMEMSGPACK: true, binding.MIMEMSGPACK: true, MIMEPROTOBUF: true,
+}
+
+// TemplateData 协商结果为text/html时使用名为Name的模板渲染Data，其他格式直接渲染Data
+type TemplateData struct {
+	Name string
+	Data any
+}
+
+// Negotiate 根据请求头Accept从offers中选择响应的格式，offers为空时支持json、xml、html和纯文本
+// Accept中的q值越大越优先，q值相同时按offers的顺序，没有Accept时使用第一个
+// 没有可以接受的格式时返回406并中止处理链，offers中有不能渲染的格式时返回500并中止处理链
+func (c *Context) Negotiate(status int, data any, offers ...string) error {
+	if len(offers) == 0 {
+		offers = []string{MIMEJSON, MIMEXML, MIMEHTML, MIMEPlain}
+	}
+	//不管请求的Accept是什么都先检查，避免只有部分请求出错
+	for _, offer := range offers {
+		if !negotiableOffers[offer] {
+			err := fmt.Errorf("%w: %s", ErrUnsupportedOffer, offer)
+			c.abortWithError(http.StatusInternalServerError, err)
+			return err
+		}
+	}
+	offer := negotiateFormat(c.R.Header.Get("Accept"), offers)
+	if offer == "" {
+		c.abortWithError(http.StatusNotAcceptable, ErrNotAcceptable)
+		return ErrNotAcceptable
+	}
+	td, isTemplate := data.(TemplateData)
+	if isTemplate {
+		data = td.Data
+	}
+	switch offer {
+	case MIMEJSON:
+		return c.JSON(status, data)
+	case MIMEXML, MIMEXML2:
+		return c.XML(status, data)
+	case MIMEHTML:
+		//没有加载模板时和普通数据一样处理
+		if isTemplate && c.engine.HTMLRender.Template != nil {
+			return c.Render(status, &render.HTML{
+				Data:       data,
+				IsTemplate: true,
+				Template:   c.engine.HTMLRender.Template,
+				Name:       td.Name,
+			})
+		}
+		//没有模板时把数据转义后作为html返回
+		return c.Render(status, &render.HTML{Data: template.HTMLEscapeString(fmt.Sprint(data))})
+	case MIMEPlain:
+		return c.String(status, "%v", data)
+	case MIMEYAML, binding.MIMEYAML:
+		return c.YAML(status, data)
+	case MIMETOML:
+		return c.TOML(status, data)
+	case MIMEMSGPACK, binding.MIMEMSGPACK:
+		return c.MsgPack(status, data)
+	case MIMEPROTOBUF:
+		return c.ProtoBuf(status, data)
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedOffer, offer)
+}
+
+// NegotiateFormat 根据请求头Accept从offers中选择最合适的格式，没有可以接受的格式时返回空串
+func (c *Context) NegotiateFormat(offers ...string) string {
+	return negotiateFormat(c.R.Header.Get("Accept"), offers)
+}
+
+// 请求头Accept中的一项，比如 text/html;q=0.9
+type acceptRange struct {
+	mediaType string
+	subType   string
+	q         float64
+}
+
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType, subType, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+		if !ok || mediaType == "" || subType == "" {
+			continue
+		}
+		r := acceptRange{mediaType: mediaType, subType: subType, q: 1}
+		for _, param := range params[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.ToLower(strings.TrimSpace(key)) != "q" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q >= 0 && q <= 1 {
+				r.q = q
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// 匹配程度，完全相同 > type/* > */*，不匹配时返回-1
+func (r acceptRange) specificity(mediaType, subType string) int {
+	switch {
+	case r.mediaType == mediaType && r.subType == subType:
+		return 2
+	case r.mediaType == mediaType && r.subType == "*":
+		return 1
+	case r.mediaType == "*" && r.subType == "*":
+		return 0
+	}
+	return -1
+}
+
+func negotiateFormat(accept string, offers []string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		mediaType, subType, _ := strings.Cut(strings.ToLower(offer), "/")
+		//一个格式的q值由匹配程度最高的一项决定，比如 text/*;q=0.5, text/html 中text/html的q值为1
+		q, level := 0.0, -1
+		for _, r := range ranges {
+			if l := r.specificity(mediaType, subType); l > level {
+				q, level = r.q, l
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// 按q值从大到小返回请求头Accept-Language中的语言，zh-CN 转换成 zh_CN 并且补充 zh
+func acceptLanguages(header string) []string {
+	type language struct {
+		tag string
+		q   float64
+	}
+	var langs []language
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(key) == "q" {
+			if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = v
+			}
+		}
+		if q > 0 {
+			langs = append(langs, language{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].q > langs[j].q
+	})
+	locales := make([]string, 0, len(langs)*2)
+	for _, l := range langs {
+		locales = append(locales, strings.ReplaceAll(l.tag, "-", "_"))
+		if base, _, ok := strings.Cut(l.tag, "-"); ok {
+			locales = append(locales, strings.ToLower(base))
+		}
+	}
+	return locales
+}
@@ -0,0 +1,92 @@
+package csgo
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	offers := []string{MIMEJSON, MIMEXML, MIMEHTML, MIMEPlain}
+	tests := []struct {
+		accept string
+		offers []string
+		want   string
+	}{
+		{"", offers, MIMEJSON},
+		{"*/*", offers, MIMEJSON},
+		{"application/xml", offers, MIMEXML},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", offers, MIMEHTML},
+		{"application/json;q=0.5, application/xml", offers, MIMEXML},
+		{"text/*;q=0.5, text/plain", offers, MIMEPlain},
+		{"text/*", []string{MIMEJSON, MIMEHTML, MIMEPlain}, MIMEHTML},
+		{"application/json;q=0, */*", offers, MIMEXML},
+		{"image/png", offers, ""},
+		{"Application/JSON", offers, MIMEJSON},
+	}
+	for _, tt := range tests {
+		if got := negotiateFormat(tt.accept, tt.offers); got != tt.want {
+			t.Errorf("Accept %q: got %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	engine := New()
+	engine.SetHtmlTemplate(template.Must(template.New("user").Parse(`<p>{{.Name}}</p>`)))
+	type user struct {
+		Name string `json:"name" xml:"name"`
+	}
+	group := engine.Group("")
+	group.Get("/user", func(ctx *Context) {
+		ctx.Negotiate(http.StatusOK, TemplateData{Name: "user", Data: user{Name: "cs"}})
+	})
+	group.Get("/json", func(ctx *Context) {
+		ctx.Negotiate(http.StatusOK, user{Name: "cs"}, MIMEJSON)
+	})
+	group.Get("/vnd", func(ctx *Context) {
+		ctx.Negotiate(http.StatusOK, user{Name: "cs"}, MIMEJSON, "application/vnd.api+json")
+	})
+
+	tests := []struct {
+		path   string
+		accept string
+		code   int
+		ctype  string
+		body   string
+	}{
+		{"/user", "application/json", http.StatusOK, "application/json; charset=utf-8", `{"name":"cs"}`},
+		{"/user", "application/xml", http.StatusOK, "application/xml; charset=utf-8", "<user><name>cs</name></user>"},
+		{"/user", "text/html", http.StatusOK, "text/html; charset=utf-8", "<p>cs</p>"},
+		{"/user", "text/plain", http.StatusOK, "text/plain; charset=utf-8", "{cs}"},
+		{"/json", "text/html", http.StatusNotAcceptable, "text/plain; charset=utf-8", "not acceptable\n"},
+		//不能渲染的格式不管是否被选中都返回500
+		{"/vnd", "application/vnd.api+json", http.StatusInternalServerError, "text/plain; charset=utf-8", "unsupported negotiation offer: application/vnd.api+json\n"},
+		{"/vnd", "application/json", http.StatusInternalServerError, "text/plain; charset=utf-8", "unsupported negotiation offer: application/vnd.api+json\n"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		r.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		if w.Code != tt.code || w.Header().Get("Content-Type") != tt.ctype || w.Body.String() != tt.body {
+			t.Errorf("%s %s: got %d %q %q", tt.path, tt.accept, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+}
+
+// 没有加载模板时，TemplateData中的数据转义后作为html返回
+func TestNegotiateWithoutTemplate(t *testing.T) {
+	engine := New()
+	engine.Group("").Get("/user", func(ctx *Context) {
+		ctx.Negotiate(http.StatusOK, TemplateData{Name: "user", Data: "<cs>"})
+	})
+	r := httptest.NewRequest(http.MethodGet, "/user", nil)
+	r.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.String() != "&lt;cs&gt;" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -21,6 +21,10 @@ func (j JSON) WriteContentType(w http.ResponseWriter) {
 
 func WriteJSON(w http.ResponseWriter, obj any) error {
 	writeContentType(w, jsonContentType[0])
+	return writeJSONBytes(w, obj)
+}
+
+func writeJSONBytes(w http.ResponseWriter, obj any) error {
 	jsonBytes, err := json.Marshal(obj)
 	if err != nil {
 		return err
@@ -28,3 +32,20 @@ func WriteJSON(w http.ResponseWriter, obj any) error {
 	_, err = w.Write(jsonBytes)
 	return err
 }
+
+// ProblemJSON RFC 7807 格式的错误信息，Content-Type为application/problem+json
+type ProblemJSON struct {
+	Data any
+}
+
+var problemJSONContentType = []string{"application/problem+json; charset=utf-8"}
+
+func (p ProblemJSON) Render(w http.ResponseWriter, status int) error {
+	p.WriteContentType(w)
+	w.WriteHeader(status)
+	return writeJSONBytes(w, p.Data)
+}
+
+func (p ProblemJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, problemJSONContentType[0])
+}
@@ -0,0 +1,32 @@
+package render
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgPack 字段名优先使用msgpack标签，没有时使用json标签
+type MsgPack struct {
+	Data any
+}
+
+var msgpackContentType = []string{"application/msgpack"}
+
+func (m *MsgPack) Render(w http.ResponseWriter, status int) error {
+	m.WriteContentType(w)
+	w.WriteHeader(status)
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(m.Data); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (m *MsgPack) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, msgpackContentType[0])
+}
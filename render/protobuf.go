@@ -0,0 +1,34 @@
+package render
+
+import (
+	"errors"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoBuf Data必须是protoc生成的消息类型
+type ProtoBuf struct {
+	Data any
+}
+
+var protobufContentType = []string{"application/x-protobuf"}
+
+func (p *ProtoBuf) Render(w http.ResponseWriter, status int) error {
+	p.WriteContentType(w)
+	w.WriteHeader(status)
+	msg, ok := p.Data.(proto.Message)
+	if !ok {
+		return errors.New("data is not a proto.Message")
+	}
+	buf, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func (p *ProtoBuf) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, protobufContentType[0])
+}
@@ -0,0 +1,112 @@
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Items 依次返回要输出的元素，ok为false时表示没有更多的元素
+// ctx在客户端断开连接时被取消，阻塞等待数据的实现应该同时等待ctx.Done()
+type Items func(ctx context.Context) (item any, ok bool, err error)
+
+// SliceItems 依次返回切片中的元素，比如 orm 中 Select 查询的结果
+func SliceItems[T any](items []T) Items {
+	i := 0
+	return func(ctx context.Context) (any, bool, error) {
+		if i >= len(items) {
+			return nil, false, nil
+		}
+		i++
+		return items[i-1], true, nil
+	}
+}
+
+// ChanItems 依次返回channel中的元素，channel关闭时结束
+// 客户端断开连接后不再读取channel，发送方需要自己结束，比如同时监听请求的ctx.Done()
+func ChanItems[T any](ch <-chan T) Items {
+	return func(ctx context.Context) (any, bool, error) {
+		select {
+		case item, ok := <-ch:
+			return item, ok, nil
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+}
+
+// JSONStream 逐个编码元素并写出，不需要把全部结果放在内存中
+// NDJSON为false时输出json数组，为true时每行一个json对象（application/x-ndjson）
+// 响应头在第一个元素之前已经发出，中途出错时只能停止输出，客户端会收到不完整的数组
+type JSONStream struct {
+	//请求的ctx，客户端断开连接时停止输出，为nil时不检查
+	Ctx   context.Context
+	Items Items
+	//每输出多少个元素刷新一次，小于等于0时每个元素都刷新
+	FlushEvery int
+	NDJSON     bool
+}
+
+var ndjsonContentType = []string{"application/x-ndjson; charset=utf-8"}
+
+func (j *JSONStream) Render(w http.ResponseWriter, status int) error {
+	j.WriteContentType(w)
+	w.WriteHeader(status)
+	ctx := j.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	if !j.NDJSON {
+		if _, err := w.Write([]byte{'['}); err != nil {
+			return err
+		}
+	}
+	for count := 0; ; count++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		item, ok, err := j.Items(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		buf, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if j.NDJSON {
+			buf = append(buf, '\n')
+		} else if count > 0 {
+			buf = append([]byte{','}, buf...)
+		}
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+		if j.FlushEvery <= 0 || (count+1)%j.FlushEvery == 0 {
+			flush()
+		}
+	}
+	if !j.NDJSON {
+		if _, err := w.Write([]byte{']'}); err != nil {
+			return err
+		}
+	}
+	flush()
+	return nil
+}
+
+func (j *JSONStream) WriteContentType(w http.ResponseWriter) {
+	if j.NDJSON {
+		writeContentType(w, ndjsonContentType[0])
+		return
+	}
+	writeContentType(w, jsonContentType[0])
+}
@@ -0,0 +1,31 @@
+package render
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/BurntSushi/toml"
+)
+
+// TOML Data必须是结构体或者map，toml的顶层不能是数组或者基本类型
+type TOML struct {
+	Data any
+}
+
+var tomlContentType = []string{"application/toml; charset=utf-8"}
+
+func (t *TOML) Render(w http.ResponseWriter, status int) error {
+	t.WriteContentType(w)
+	w.WriteHeader(status)
+	//先编码到缓冲区，编码失败时不会写出一半的内容
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(t.Data); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (t *TOML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, tomlContentType[0])
+}
@@ -0,0 +1,28 @@
+package render
+
+import (
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+type YAML struct {
+	Data any
+}
+
+var yamlContentType = []string{"application/yaml; charset=utf-8"}
+
+func (y *YAML) Render(w http.ResponseWriter, status int) error {
+	y.WriteContentType(w)
+	w.WriteHeader(status)
+	buf, err := yaml.Marshal(y.Data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func (y *YAML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, yamlContentType[0])
+}
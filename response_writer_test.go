@@ -0,0 +1,103 @@
+package csgo
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseWriterStatus(t *testing.T) {
+	engine := New()
+	var out bytes.Buffer
+	var logged []int
+	engine.Use(func(next HandleFunc) HandleFunc {
+		return LoggingWithConfig(LoggingConfig{
+			Formatter: func(params *LogFormatterParams) string {
+				logged = append(logged, params.StatusCode)
+				return ""
+			},
+			out: &out,
+		}, next)
+	})
+	group := engine.Group("")
+	//只写状态码不写响应体
+	group.Get("/unauth", func(ctx *Context) {
+		ctx.W.WriteHeader(http.StatusUnauthorized)
+	})
+	//写入响应体之前仍然可以修改响应头
+	group.Get("/created", func(ctx *Context) {
+		ctx.W.WriteHeader(http.StatusCreated)
+		ctx.W.Header().Set("Location", "/user/1")
+		ctx.W.WriteString("created")
+	})
+	//响应已经发送后再次写入状态码被忽略
+	group.Get("/twice", func(ctx *Context) {
+		ctx.JSON(http.StatusOK, map[string]string{"name": "cs"})
+		ctx.JSON(http.StatusInternalServerError, map[string]string{"error": "twice"})
+		if ctx.W.Status() != http.StatusOK || ctx.W.Size() != len(`{"name":"cs"}{"error":"twice"}`) {
+			t.Errorf("status = %d, size = %d", ctx.W.Status(), ctx.W.Size())
+		}
+	})
+
+	tests := []struct {
+		path     string
+		code     int
+		location string
+		body     string
+	}{
+		{"/unauth", http.StatusUnauthorized, "", ""},
+		{"/created", http.StatusCreated, "/user/1", "created"},
+		{"/twice", http.StatusOK, "", `{"name":"cs"}{"error":"twice"}`},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.code || w.Header().Get("Location") != tt.location || w.Body.String() != tt.body {
+			t.Errorf("%s: got %d %q %q", tt.path, w.Code, w.Header().Get("Location"), w.Body.String())
+		}
+		if logged[i] != tt.code {
+			t.Errorf("%s: logged status %d, want %d", tt.path, logged[i], tt.code)
+		}
+	}
+}
+
+func TestResponseWriterFlushAndHijack(t *testing.T) {
+	engine := New()
+	group := engine.Group("")
+	group.Get("/flush", func(ctx *Context) {
+		ctx.W.WriteString("part")
+		ctx.W.Flush()
+	})
+	group.Get("/hijack", func(ctx *Context) {
+		conn, buf, err := ctx.W.Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 6\r\nConnection: close\r\n\r\nhijack")
+		buf.Flush()
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/flush", nil))
+	if !w.Flushed || w.Body.String() != "part" {
+		t.Errorf("flushed = %v, body = %q", w.Flushed, w.Body.String())
+	}
+
+	addr, done := startServer(t, engine, nil)
+	defer func() {
+		engine.Shutdown()
+		<-done
+	}()
+	resp, err := http.Get(addr + "/hijack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "hijack" {
+		t.Errorf("body = %q", body)
+	}
+}
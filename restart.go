@@ -0,0 +1,80 @@
+package csgo
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 热重启时父进程传给子进程的环境变量
+const (
+	//继承的监听器数目，监听器从文件描述符3开始
+	envListenFds = "CSGO_LISTEN_FDS"
+	//子进程开始提供服务后，向这个文件描述符写入一个字节通知父进程
+	envReadyFd = "CSGO_READY_FD"
+)
+
+// 去掉当前进程从父进程继承的热重启环境变量
+func restartEnv() []string {
+	env := make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, envListenFds+"=") || strings.HasPrefix(kv, envReadyFd+"=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// 热重启启动的新进程使用父进程传递的监听器，其他情况调用listen获得监听器
+// 所有的启动方法都通过它获得监听器，这样新进程不论用哪种方法启动都能接管父进程的监听器
+func listenOrInherit(listen func() ([]net.Listener, error)) ([]net.Listener, error) {
+	listeners, err := inheritedListeners()
+	if err != nil || listeners != nil {
+		return listeners, err
+	}
+	return listen()
+}
+
+// 监听一个地址
+func listenOne(network, addr string) func() ([]net.Listener, error) {
+	return func() ([]net.Listener, error) {
+		l, err := net.Listen(network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{l}, nil
+	}
+}
+
+// 获得热重启时父进程传递的监听器，不是热重启启动的进程返回nil
+func inheritedListeners() ([]net.Listener, error) {
+	value := os.Getenv(envListenFds)
+	if value == "" {
+		return nil, nil
+	}
+	os.Unsetenv(envListenFds)
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("%s 不正确: %s", envListenFds, value)
+	}
+	return fdListeners(listenFdsStart, n, nil)
+}
+
+// 通知父进程新进程已经开始提供服务
+func notifyReady() {
+	value := os.Getenv(envReadyFd)
+	if value == "" {
+		return
+	}
+	os.Unsetenv(envReadyFd)
+	fd, err := strconv.Atoi(value)
+	if err != nil {
+		return
+	}
+	f := os.NewFile(uintptr(fd), "ready")
+	f.Write([]byte{1})
+	f.Close()
+}
@@ -0,0 +1,13 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris)
+
+package csgo
+
+import (
+	"errors"
+	"net"
+)
+
+// 当前系统不能把监听器传递给新进程，收到SIGHUP时继续使用当前进程提供服务
+func (e *Engine) restart(listeners []net.Listener) error {
+	return errors.New("当前系统不支持热重启")
+}
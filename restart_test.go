@@ -0,0 +1,142 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package csgo
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+const (
+	envHotRestartAddr = "CSGO_HOT_RESTART_ADDR"
+	//为activated时第一个进程模拟systemd的socket activation，用RunActivated启动
+	envHotRestartMode = "CSGO_HOT_RESTART_MODE"
+)
+
+// 热重启测试启动的服务进程，新旧两个进程都运行这个测试
+func TestHotRestartHelper(t *testing.T) {
+	addr := os.Getenv(envHotRestartAddr)
+	if addr == "" {
+		t.Skip("只在热重启测试启动的进程中运行")
+	}
+	engine := New()
+	engine.HotRestart = true
+	group := engine.Group("")
+	group.Get("/pid", func(ctx *Context) {
+		ctx.String(http.StatusOK, strconv.Itoa(os.Getpid()))
+	})
+	group.Get("/slow", func(ctx *Context) {
+		time.Sleep(300 * time.Millisecond)
+		ctx.String(http.StatusOK, "slow")
+	})
+	var err error
+	if os.Getenv(envHotRestartMode) == "activated" {
+		//systemd在启动进程时设置LISTEN_PID，这里由进程自己设置
+		if os.Getenv("LISTEN_FDS") != "" {
+			os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+		}
+		err = engine.RunActivated()
+	} else {
+		err = engine.RunServer(addr, &ServerOptions{ShutdownTimeout: 5 * time.Second})
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestHotRestart(t *testing.T) {
+	if testing.Short() {
+		t.Skip("需要启动多个进程")
+	}
+	t.Run("RunServer", func(t *testing.T) {
+		testHotRestart(t, "")
+	})
+	//新进程中 LISTEN_FDS 已经被清除，也要使用父进程传递的监听器
+	t.Run("RunActivated", func(t *testing.T) {
+		testHotRestart(t, "activated")
+	})
+}
+
+func testHotRestart(t *testing.T, mode string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHotRestartHelper$")
+	cmd.Env = append(os.Environ(), envHotRestartAddr+"="+addr, envHotRestartMode+"="+mode)
+	if mode == "activated" {
+		f, err := l.(*net.TCPListener).File()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		cmd.ExtraFiles = []*os.File{f}
+		cmd.Env = append(cmd.Env, "LISTEN_FDS=1")
+	}
+	err = cmd.Start()
+	l.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func(path string) (string, error) {
+		resp, err := client.Get("http://" + addr + path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+	waitPid := func(old string) string {
+		deadline := time.Now().Add(10 * time.Second)
+		for time.Now().Before(deadline) {
+			if pid, err := get("/pid"); err == nil && pid != old {
+				return pid
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("no new process serving on %s", addr)
+		return ""
+	}
+
+	oldPid := waitPid("")
+	slow := make(chan string, 1)
+	go func() {
+		body, err := get("/slow")
+		if err != nil {
+			body = err.Error()
+		}
+		slow <- body
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if err := cmd.Process.Signal(syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+
+	newPid := waitPid(oldPid)
+	pid, _ := strconv.Atoi(newPid)
+	defer syscall.Kill(pid, syscall.SIGKILL)
+	if body := <-slow; body != "slow" {
+		t.Errorf("in-flight request on the old process got %q", body)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("old process exited with %v", err)
+	}
+	if body, err := get("/pid"); err != nil || body != newPid {
+		t.Errorf("after restart got %q %v, want %s", body, err, newPid)
+	}
+	syscall.Kill(pid, syscall.SIGTERM)
+}
@@ -0,0 +1,117 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package csgo
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// 等待新进程开始提供服务的最长时间
+const restartReadyTimeout = 30 * time.Second
+
+// 启动一个新的进程，把监听器通过文件描述符传递给它，新进程开始提供服务后返回
+// 返回nil后当前进程应该停止接收新的连接，处理完正在处理的请求后退出
+func (e *Engine) restart(listeners []net.Listener) error {
+	files := make([]*os.File, 0, len(listeners)+1)
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, l := range listeners {
+		f, err := listenerFile(l)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	files = append(files, w)
+
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(restartEnv(),
+		envListenFds+"="+strconv.Itoa(len(listeners)),
+		envReadyFd+"="+strconv.Itoa(listenFdsStart+len(listeners)),
+	)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	//关闭父进程中的写端，子进程退出时读端才能读到EOF
+	w.Close()
+	files = files[:len(files)-1]
+
+	ready := make(chan error, 1)
+	go func() {
+		_, err := r.Read(make([]byte, 1))
+		ready <- err
+	}()
+	select {
+	case err = <-ready:
+	case <-time.After(restartReadyTimeout):
+		err = errors.New("等待新进程启动超时")
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("新进程没有开始提供服务: %w", err)
+	}
+	//监听器已经交给新进程，关闭时不能删除Unix套接字文件
+	for _, l := range listeners {
+		if ul, ok := l.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+	}
+	return cmd.Process.Release()
+}
+
+// 复制监听器的文件描述符，用来传递给新进程
+// 不使用 File()，它返回的文件在 exec 调用 Fd() 时会把套接字设置为阻塞模式，套接字和当前进程的监听器共享，
+// 这时再进入accept的协程会阻塞在系统调用中，不能被Close唤醒，退出时会一直等待
+// 直接复制的非阻塞文件描述符交给 os.NewFile 后，Fd() 不会改变它的模式
+func listenerFile(l net.Listener) (*os.File, error) {
+	sc, ok := l.(syscall.Conn)
+	if !ok {
+		return nil, fmt.Errorf("监听器 %s 不能传递给新进程", l.Addr())
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	fd := -1
+	var dupErr error
+	err = rc.Control(func(s uintptr) {
+		//和创建子进程互斥，避免复制出的文件描述符在设置CloseOnExec之前被其他子进程继承
+		syscall.ForkLock.RLock()
+		defer syscall.ForkLock.RUnlock()
+		fd, dupErr = syscall.Dup(int(s))
+		if dupErr == nil {
+			syscall.CloseOnExec(fd)
+		}
+	})
+	if err == nil {
+		err = dupErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), "listener"), nil
+}
@@ -0,0 +1,59 @@
+package csgo
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+)
+
+// RouteInfo 一条已注册路由的信息
+type RouteInfo struct {
+	Method string
+	//完整路径，包含组的前缀
+	Path string
+	//处理方法的函数名
+	Handler string
+	//路由生效的中间件数目，包含引擎、组、父组以及路由级别的中间件
+	Middlewares int
+}
+
+// Routes 返回所有组中注册的路由，按路径和请求方式排序
+func (e *Engine) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0)
+	for _, group := range e.routerGroups {
+		groupMiddlewares := len(e.middle)
+		for g := group; g != nil; g = g.parent {
+			groupMiddlewares += len(g.middlewares)
+		}
+		for name, handles := range group.handleFuncMap {
+			for method, handle := range handles {
+				routes = append(routes, RouteInfo{
+					Method:      method,
+					Path:        group.fullPath(name),
+					Handler:     nameOfFunction(handle),
+					Middlewares: groupMiddlewares + len(group.middlewareFuncMap[name][method]),
+				})
+			}
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// 打印路由表，在启动时检查暴露了哪些接口
+func (e *Engine) printRoutes() {
+	for _, route := range e.Routes() {
+		fmt.Fprintf(DefaultWriter, "[csgo] %-7s %-40s --> %s (%d middlewares)\n",
+			route.Method, route.Path, route.Handler, route.Middlewares)
+	}
+}
+
+func nameOfFunction(f any) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
@@ -0,0 +1,275 @@
+package csgo
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"web/csgo/config"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// 默认等待正在处理的请求完成的时间
+const defaultShutdownTimeout = 10 * time.Second
+
+// ServerOptions http服务器的配置，零值表示不限制
+type ServerOptions struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	//收到退出信号后，等待正在处理的请求完成的最长时间
+	ShutdownTimeout time.Duration
+	//触发优雅退出的信号，默认为SIGINT和SIGTERM
+	Signals []os.Signal
+}
+
+// DefaultServerOptions 从配置文件的 [server] 中读取服务器配置
+// 时间可以写成 "5s" 这样的字符串，也可以写成整数秒
+func DefaultServerOptions() *ServerOptions {
+	conf := config.Conf.Server
+	return &ServerOptions{
+		ReadTimeout:       confDuration(conf, "read_timeout", 0),
+		ReadHeaderTimeout: confDuration(conf, "read_header_timeout", 0),
+		WriteTimeout:      confDuration(conf, "write_timeout", 0),
+		IdleTimeout:       confDuration(conf, "idle_timeout", 0),
+		MaxHeaderBytes:    confInt(conf, "max_header_bytes", 0),
+		ShutdownTimeout:   confDuration(conf, "shutdown_timeout", defaultShutdownTimeout),
+	}
+}
+
+func confDuration(conf map[string]any, key string, defaultValue time.Duration) time.Duration {
+	switch value := conf[key].(type) {
+	case int64:
+		return time.Duration(value) * time.Second
+	case string:
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+func confInt(conf map[string]any, key string, defaultValue int) int {
+	if value, ok := conf[key].(int64); ok {
+		return int(value)
+	}
+	return defaultValue
+}
+
+// OnShutdown 注册退出时执行的方法，比如关闭数据库连接 engine.OnShutdown(db.Close)
+// 在所有请求处理完成后按注册的顺序执行，没有返回值的方法使用 OnShutdownFunc 注册
+func (e *Engine) OnShutdown(hooks ...func() error) {
+	e.shutdownHooks = append(e.shutdownHooks, hooks...)
+}
+
+// OnShutdownFunc 注册没有返回值的退出方法，比如释放协程池 engine.OnShutdownFunc(pool.Release)
+// 和 OnShutdown 注册的方法按注册的顺序一起执行
+func (e *Engine) OnShutdownFunc(hooks ...func()) {
+	for _, hook := range hooks {
+		hook := hook
+		e.OnShutdown(func() error {
+			hook()
+			return nil
+		})
+	}
+}
+
+// Shutdown 让正在运行的服务器优雅退出，效果和收到退出信号一样
+func (e *Engine) Shutdown() {
+	e.quitOnce.Do(func() {
+		close(e.quit)
+	})
+}
+
+// RunServer 在addr上启动http服务器，opts为nil时使用配置文件中的配置
+// 收到退出信号或者调用Shutdown后，停止接收新的请求，等待正在处理的请求完成后执行退出方法再返回
+func (e *Engine) RunServer(addr string, opts *ServerOptions) error {
+	//热重启时由父进程传递监听器，不再重新监听
+	listeners, err := listenOrInherit(listenOne("tcp", addr))
+	if err != nil {
+		return err
+	}
+	return e.serveAll(listeners, opts, nil)
+}
+
+// 开启H2C时返回的h2cRequests用于退出时等待h2c连接上的请求，没有开启时为nil
+func (e *Engine) newServer(opts *ServerOptions) (*http.Server, *h2cRequests) {
+	srv := &http.Server{
+		Handler:           e,
+		ReadTimeout:       opts.ReadTimeout,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
+	}
+	if !e.H2C {
+		return srv, nil
+	}
+	//不使用TLS的HTTP/2，支持直接发起的h2c连接和HTTP/1.1的Upgrade
+	requests := &h2cRequests{handler: e}
+	h2s := &http2.Server{IdleTimeout: opts.IdleTimeout}
+	//Shutdown时向h2c连接发送GOAWAY，TLSConfig为nil时不会返回错误
+	_ = http2.ConfigureServer(srv, h2s)
+	srv.Handler = h2c.NewHandler(requests, h2s)
+	return srv, requests
+}
+
+// h2cRequests h2c的连接被h2c.NewHandler劫持后不再由http.Server管理，Shutdown不会等待上面的请求
+// 这里记录正在处理的请求，退出时在Shutdown之后继续等待它们完成
+type h2cRequests struct {
+	handler http.Handler
+	active  int64
+}
+
+func (h *h2cRequests) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt64(&h.active, 1)
+	defer atomic.AddInt64(&h.active, -1)
+	h.handler.ServeHTTP(w, r)
+}
+
+// 等待正在处理的请求完成，超时返回ctx的错误
+func (h *h2cRequests) wait(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for atomic.LoadInt64(&h.active) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// 在监听器上提供服务，直到出错或者需要退出
+func (e *Engine) serve(l net.Listener, opts *ServerOptions) error {
+	return e.serveAll([]net.Listener{l}, opts, nil)
+}
+
+// 同一个服务器同时在多个监听器上提供服务，任意一个出错时全部退出，tlsConfig不为nil时提供https服务
+func (e *Engine) serveAll(listeners []net.Listener, opts *ServerOptions, tlsConfig *tls.Config) error {
+	if opts == nil {
+		opts = DefaultServerOptions()
+	}
+	if e.PrintRoutes {
+		e.printRoutes()
+	}
+	srv, h2cReqs := e.newServer(opts)
+	srv.TLSConfig = tlsConfig
+
+	signals := opts.Signals
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, signals...)
+	defer signal.Stop(sig)
+
+	hup := make(chan os.Signal, 1)
+	if e.HotRestart {
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+	}
+
+	serveErr := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			if tlsConfig != nil {
+				//证书由tlsConfig提供，ServeTLS会同时开启HTTP/2
+				serveErr <- srv.ServeTLS(l, "", "")
+				return
+			}
+			serveErr <- srv.Serve(l)
+		}(l)
+	}
+	//如果是热重启启动的新进程，通知父进程可以退出了
+	notifyReady()
+
+wait:
+	for {
+		select {
+		case err := <-serveErr:
+			//服务器自己出错退出，关闭其他的监听器
+			return joinErrors(err, srv.Close(), e.runShutdownHooks())
+		case <-hup:
+			//新进程启动失败时继续提供服务
+			if err := e.restart(listeners); err != nil {
+				log.Println("hot restart failed:", err)
+				continue
+			}
+			break wait
+		case <-sig:
+			break wait
+		case <-e.quit:
+			break wait
+		}
+	}
+	timeout := opts.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := srv.Shutdown(ctx)
+	if h2cReqs != nil && err == nil {
+		err = h2cReqs.wait(ctx)
+	}
+	return joinErrors(err, e.runShutdownHooks())
+}
+
+// 执行全部的退出方法，只执行一次
+func (e *Engine) runShutdownHooks() error {
+	var errs []error
+	e.hooksOnce.Do(func() {
+		for _, hook := range e.shutdownHooks {
+			errs = append(errs, hook())
+		}
+	})
+	return joinErrors(errs...)
+}
+
+// ShutdownError 退出过程中发生的多个错误
+type ShutdownError []error
+
+func (err ShutdownError) Error() string {
+	var b strings.Builder
+	for i, e := range err {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(e.Error())
+	}
+	return b.String()
+}
+
+// 去掉nil，没有错误返回nil，只有一个错误时直接返回这个错误
+func joinErrors(errs ...error) error {
+	var shutdownError ShutdownError
+	for _, err := range errs {
+		var se ShutdownError
+		if errors.As(err, &se) {
+			shutdownError = append(shutdownError, se...)
+		} else if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			shutdownError = append(shutdownError, err)
+		}
+	}
+	switch len(shutdownError) {
+	case 0:
+		return nil
+	case 1:
+		return shutdownError[0]
+	}
+	return shutdownError
+}
@@ -0,0 +1,150 @@
+package csgo
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+	"web/csgo/cspool"
+	"web/csgo/orm"
+)
+
+// 启动服务器，返回服务地址以及服务器退出时的错误
+func startServer(t *testing.T, engine *Engine, opts *ServerOptions) (string, chan error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.serve(l, opts)
+	}()
+	return "http://" + l.Addr().String(), done
+}
+
+func TestGracefulShutdownOnSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("windows不能给进程发送SIGTERM")
+	}
+	engine := New()
+	started := make(chan struct{})
+	group := engine.Group("")
+	group.Get("/ping", func(ctx *Context) {
+		ctx.String(http.StatusOK, "pong")
+	})
+	group.Get("/slow", func(ctx *Context) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		ctx.String(http.StatusOK, "done")
+	})
+	var hooks []string
+	engine.OnShutdown(func() error {
+		hooks = append(hooks, "db")
+		return nil
+	}, func() error {
+		hooks = append(hooks, "pool")
+		return nil
+	})
+
+	addr, done := startServer(t, engine, &ServerOptions{ShutdownTimeout: 5 * time.Second})
+	//第一个请求成功说明服务器已经在监听退出信号
+	if _, err := http.Get(addr + "/ping"); err != nil {
+		t.Fatal(err)
+	}
+
+	result := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(addr + "/slow")
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		result <- string(body)
+	}()
+	<-started
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	if body := <-result; body != "done" {
+		t.Errorf("in-flight request got %q, want done", body)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("serve returned %v", err)
+	}
+	if len(hooks) != 2 || hooks[0] != "db" || hooks[1] != "pool" {
+		t.Errorf("hooks = %v", hooks)
+	}
+}
+
+func TestShutdownReturnsHookErrors(t *testing.T) {
+	engine := New()
+	errDb := errors.New("close db")
+	errPool := errors.New("release pool")
+	engine.OnShutdown(func() error { return errDb }, func() error { return nil }, func() error { return errPool })
+
+	_, done := startServer(t, engine, nil)
+	engine.Shutdown()
+	err := <-done
+	var shutdownError ShutdownError
+	if !errors.As(err, &shutdownError) || len(shutdownError) != 2 {
+		t.Fatalf("err = %v, want two errors", err)
+	}
+	if !errors.Is(shutdownError[0], errDb) || !errors.Is(shutdownError[1], errPool) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+// 只记录连接是否被关闭的数据库驱动
+type closeDriver struct{ closed *bool }
+
+func (d closeDriver) Open(string) (driver.Conn, error) { return closeConn(d), nil }
+
+type closeConn struct{ closed *bool }
+
+func (c closeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c closeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+func (c closeConn) Close() error {
+	*c.closed = true
+	return nil
+}
+
+var dbClosed bool
+
+func init() {
+	sql.Register("csgo-close", closeDriver{closed: &dbClosed})
+}
+
+// 关闭数据库返回error，释放协程池没有返回值，分别用 OnShutdown 和 OnShutdownFunc 注册
+func TestShutdownClosesDbAndPool(t *testing.T) {
+	engine := New()
+	db := orm.Open("csgo-close", "")
+	pool, err := cspool.NewPool(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.OnShutdown(db.Close)
+	engine.OnShutdownFunc(pool.Release)
+
+	_, done := startServer(t, engine, nil)
+	engine.Shutdown()
+	if err := <-done; err != nil {
+		t.Fatalf("serve returned %v", err)
+	}
+	if !dbClosed {
+		t.Error("db was not closed")
+	}
+	if !pool.IsClosed() {
+		t.Error("pool was not released")
+	}
+}
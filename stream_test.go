@@ -0,0 +1,109 @@
+package csgo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"web/csgo/render"
+)
+
+func TestJSONStream(t *testing.T) {
+	type row struct {
+		Id   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	rows := []row{{1, "a"}, {2, "b"}, {3, "c"}}
+	engine := New()
+	g := engine.Group("")
+	g.Get("/array", func(ctx *Context) {
+		ctx.JSONStream(http.StatusOK, render.SliceItems(rows))
+	})
+	g.Get("/empty", func(ctx *Context) {
+		ctx.JSONStream(http.StatusOK, render.SliceItems([]row{}))
+	})
+	g.Get("/ndjson", func(ctx *Context) {
+		ch := make(chan row)
+		go func() {
+			defer close(ch)
+			for _, r := range rows {
+				ch <- r
+			}
+		}()
+		ctx.NDJSONStream(http.StatusOK, render.ChanItems(ch))
+	})
+
+	tests := []struct {
+		path        string
+		contentType string
+		want        string
+	}{
+		{"/array", "application/json; charset=utf-8", `[{"id":1,"name":"a"},{"id":2,"name":"b"},{"id":3,"name":"c"}]`},
+		{"/empty", "application/json; charset=utf-8", `[]`},
+		{"/ndjson", "application/x-ndjson; charset=utf-8", "{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":\"b\"}\n{\"id\":3,\"name\":\"c\"}\n"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != tt.want {
+			t.Errorf("%s: got %d %s", tt.path, w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("%s: Content-Type = %q", tt.path, ct)
+		}
+		if !w.Flushed {
+			t.Errorf("%s: not flushed", tt.path)
+		}
+	}
+}
+
+// 每个元素写出后客户端就能读到，客户端断开连接后处理方法停止输出
+func TestJSONStreamDisconnect(t *testing.T) {
+	engine := New()
+	result := make(chan error, 1)
+	engine.Group("").Get("/events", func(ctx *Context) {
+		ch := make(chan int)
+		go func() {
+			defer close(ch)
+			for i := 0; ; i++ {
+				select {
+				case ch <- i:
+				case <-ctx.R.Context().Done():
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}()
+		result <- ctx.NDJSONStream(http.StatusOK, render.ChanItems(ch))
+	})
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(reqCtx, http.MethodGet, srv.URL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	for _, want := range []string{"0\n", "1\n", "2\n"} {
+		line, err := reader.ReadString('\n')
+		if err != nil || line != want {
+			t.Fatalf("got %q %v, want %q", line, err, want)
+		}
+	}
+	cancel()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not stop after the client disconnected")
+	}
+}
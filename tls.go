@@ -0,0 +1,151 @@
+package csgo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSOptions https服务器的配置
+type TLSOptions struct {
+	CertFile string
+	KeyFile  string
+	//最低的TLS版本，默认为TLS1.2
+	MinVersion uint16
+	//允许的加密套件，为空时使用go的默认配置，TLS1.3的加密套件不能配置
+	CipherSuites []uint16
+	//校验客户端证书使用的CA证书，设置后默认要求客户端提供证书（mTLS）
+	ClientCAFile string
+	//客户端证书的校验方式，ClientCAFile不为空并且这里为零值时使用RequireAndVerifyClientCert
+	ClientAuth tls.ClientAuthType
+	//检查证书文件是否更新的间隔，文件修改后新的连接使用新的证书，0表示不重新加载
+	ReloadInterval time.Duration
+}
+
+// 根据配置生成tls.Config
+func (o *TLSOptions) tlsConfig() (*tls.Config, error) {
+	if o == nil || o.CertFile == "" || o.KeyFile == "" {
+		return nil, errors.New("证书文件和私钥文件不能为空")
+	}
+	reloader, err := newCertReloader(o.CertFile, o.KeyFile, o.ReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		MinVersion:     o.MinVersion,
+		CipherSuites:   o.CipherSuites,
+		ClientAuth:     o.ClientAuth,
+		GetCertificate: reloader.GetCertificate,
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	if o.ClientCAFile != "" {
+		pem, err := os.ReadFile(o.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s 中没有可用的证书", o.ClientCAFile)
+		}
+		cfg.ClientCAs = pool
+		if cfg.ClientAuth == tls.NoClientCert {
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return cfg, nil
+}
+
+// 证书文件修改后重新加载证书，在握手时检查，不需要额外的协程
+type certReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+func newCertReloader(certFile, keyFile string, interval time.Duration) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) load() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.mu.Unlock()
+	return nil
+}
+
+// 证书文件和私钥文件中较新的修改时间
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, name := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// GetCertificate 握手时返回当前的证书，距离上次检查超过间隔时检查文件是否修改
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if r.interval > 0 {
+		r.mu.Lock()
+		check := time.Since(r.lastCheck) >= r.interval
+		if check {
+			r.lastCheck = time.Now()
+		}
+		modTime := r.modTime
+		r.mu.Unlock()
+		if check {
+			if latest, err := r.latestModTime(); err == nil && latest.After(modTime) {
+				//加载失败时继续使用原来的证书，比如证书和私钥只更新了一个
+				if err := r.load(); err != nil {
+					log.Println("reload certificate failed:", err)
+				}
+			}
+		}
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// RunServerTLS 在addr上启动https服务器，opts为nil时使用配置文件中的配置
+func (e *Engine) RunServerTLS(addr string, tlsOpts *TLSOptions, opts *ServerOptions) error {
+	cfg, err := tlsOpts.tlsConfig()
+	if err != nil {
+		return err
+	}
+	listeners, err := listenOrInherit(listenOne("tcp", addr))
+	if err != nil {
+		return err
+	}
+	return e.serveAll(listeners, opts, cfg)
+}
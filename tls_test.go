@@ -0,0 +1,285 @@
+package csgo
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// 测试时生成的证书和私钥
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// 生成证书，parent为nil时生成自签名的CA证书
+func newTestCert(t *testing.T, name string, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// 把证书和私钥写入文件，返回文件路径
+func (c *testCert) write(t *testing.T, dir, name string) (string, string) {
+	keyDer, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func (c *testCert) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(c.cert)
+	return pool
+}
+
+// 启动https服务器，测试结束时关闭
+func startTLSServer(t *testing.T, engine *Engine, opts *TLSOptions) string {
+	cfg, err := opts.tlsConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.serveAll([]net.Listener{l}, &ServerOptions{ShutdownTimeout: time.Second}, cfg)
+	}()
+	t.Cleanup(func() {
+		engine.Shutdown()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	})
+	return "https://" + l.Addr().String()
+}
+
+// 每次请求都建立新的连接，方便检查握手的结果
+func tlsClient(cfg *tls.Config) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   cfg,
+		ForceAttemptHTTP2: true,
+		DisableKeepAlives: true,
+	}}
+}
+
+func TestRunServerTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil, x509.ExtKeyUsageAny)
+	certFile, keyFile := newTestCert(t, "server", ca, x509.ExtKeyUsageServerAuth).write(t, dir, "server")
+	addr := startTLSServer(t, newPingEngine(), &TLSOptions{
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		MinVersion: tls.VersionTLS13,
+	})
+
+	resp, err := tlsClient(&tls.Config{RootCAs: ca.pool()}).Get(addr + "/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Errorf("proto = %s, want HTTP/2", resp.Proto)
+	}
+	if resp.TLS.Version != tls.VersionTLS13 {
+		t.Errorf("tls version = %x", resp.TLS.Version)
+	}
+
+	//低于最低版本的客户端握手失败
+	_, err = tlsClient(&tls.Config{RootCAs: ca.pool(), MaxVersion: tls.VersionTLS12}).Get(addr + "/ping")
+	if err == nil {
+		t.Error("TLS1.2 client should be rejected")
+	}
+}
+
+func TestRunServerTLSClientCert(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil, x509.ExtKeyUsageAny)
+	certFile, keyFile := newTestCert(t, "server", ca, x509.ExtKeyUsageServerAuth).write(t, dir, "server")
+	caFile, _ := ca.write(t, dir, "ca")
+	addr := startTLSServer(t, newPingEngine(), &TLSOptions{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+	})
+
+	client := newTestCert(t, "client", ca, x509.ExtKeyUsageClientAuth)
+	cfg := &tls.Config{RootCAs: ca.pool(), Certificates: []tls.Certificate{client.tlsCertificate()}}
+	if body := get(t, tlsClient(cfg), addr+"/ping"); body != "pong" {
+		t.Errorf("body = %q", body)
+	}
+
+	//没有证书或者证书不是由CA签发的客户端被拒绝
+	other := newTestCert(t, "other", nil, x509.ExtKeyUsageClientAuth)
+	for _, certs := range [][]tls.Certificate{nil, {other.tlsCertificate()}} {
+		_, err := tlsClient(&tls.Config{RootCAs: ca.pool(), Certificates: certs}).Get(addr + "/ping")
+		if err == nil {
+			t.Error("client without a valid certificate should be rejected")
+		}
+	}
+}
+
+func TestRunServerTLSReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", nil, x509.ExtKeyUsageAny)
+	certFile, keyFile := newTestCert(t, "old", ca, x509.ExtKeyUsageServerAuth).write(t, dir, "server")
+	addr := startTLSServer(t, newPingEngine(), &TLSOptions{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ReloadInterval: time.Millisecond,
+	})
+	client := tlsClient(&tls.Config{RootCAs: ca.pool()})
+	commonName := func() string {
+		resp, err := client.Get(addr + "/ping")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	if name := commonName(); name != "old" {
+		t.Fatalf("certificate = %s, want old", name)
+	}
+
+	newTestCert(t, "new", ca, x509.ExtKeyUsageServerAuth).write(t, dir, "server")
+	//文件系统的时间精度可能不够，直接把修改时间往后调
+	future := time.Now().Add(time.Minute)
+	for _, name := range []string{certFile, keyFile} {
+		if err := os.Chtimes(name, future, future); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	if name := commonName(); name != "new" {
+		t.Errorf("certificate = %s, want new", name)
+	}
+}
+
+func TestH2C(t *testing.T) {
+	engine := newPingEngine()
+	engine.H2C = true
+	addr, done := startServer(t, engine, nil)
+	defer func() {
+		engine.Shutdown()
+		<-done
+	}()
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	resp, err := client.Get(addr + "/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Errorf("proto = %s, want HTTP/2", resp.Proto)
+	}
+
+	//普通的HTTP/1.1请求仍然可以访问
+	if body := get(t, http.DefaultClient, addr+"/ping"); body != "pong" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+// h2c连接被劫持，退出时也要等待上面正在处理的请求
+func TestH2CShutdown(t *testing.T) {
+	engine := New()
+	started := make(chan struct{})
+	engine.Group("").Get("/slow", func(ctx *Context) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+		ctx.String(http.StatusOK, "done")
+	})
+	engine.H2C = true
+	addr, done := startServer(t, engine, nil)
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	result := make(chan string, 1)
+	go func() {
+		resp, err := client.Get(addr + "/slow")
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		result <- resp.Proto + " " + string(body)
+	}()
+	<-started
+	start := time.Now()
+	engine.Shutdown()
+	if err := <-done; err != nil {
+		t.Errorf("serve returned %v", err)
+	}
+	//处理方法还要执行300ms，服务器应该等它完成后才返回
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("server returned after %v, before the h2c request finished", elapsed)
+	}
+	if body := <-result; body != "HTTP/2.0 done" {
+		t.Errorf("got %q", body)
+	}
+}
@@ -1,95 +1,281 @@
 package csgo
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
+// 节点类型，匹配时的优先级 static > param > catchAll
+type nodeType uint8
+
+const (
+	// 静态节点，path为压缩后的公共前缀
+	static nodeType = iota
+	// 参数节点，:id 或者 * 匹配一段路径
+	param
+	// 通配节点，** 匹配剩余的全部路径，只能出现在路由的最后
+	catchAll
+)
+
+// treeNode 压缩前缀树（radix tree）的节点
+// 静态子节点按首字节索引，通配子节点最多一个
+// 参数子节点可以有多个，带约束的按注册顺序排在前面，不带约束的最多一个并且排在最后
 type treeNode struct {
-	name       string
-	children   []*treeNode
+	path  string
+	nType nodeType
+	//参数节点的参数名和约束，约束为nil时匹配任意一段路径
+	key        string
+	constraint *paramConstraint
+	//静态子节点的首字节，和children一一对应
+	indices       string
+	children      []*treeNode
+	paramChildren []*treeNode
+	catchAllChild *treeNode
+	//是否是一条路由的结尾
+	isEnd bool
+	//完整的路由，比如 /user/get/:id
 	routerName string
-	isEnd      bool
+	//注册这条路由的组，以及在组中注册时使用的名字
+	group *routerGroup
+	name  string
 }
 
-//put path:/user/get/:id
-func (t *treeNode) Put(path string) {
-	root := t
-	strs := strings.Split(path, "/")
-	//所有
-	for index, name := range strs {
-		if index == 0 {
-			continue
+// Put 添加一条路由，返回路由对应的叶子节点 path:/user/get/:id
+// 同一位置参数名不同、** 不在路由最后时会panic
+func (t *treeNode) Put(path string) *treeNode {
+	if path == "" || path[0] != '/' {
+		panic(fmt.Sprintf("路由 %s 必须以 / 开头", path))
+	}
+	n := t
+	rest := path
+	for rest != "" {
+		seg := rest
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			seg = rest[:i]
 		}
-		children := t.children
-		isMatch := false
-		for _, node := range children {
-			//判断所有子树路径是否和传入的路径相匹配，如果有对应匹配
-			//就说明树中对应存在，直接跳过循环
-			if node.name == name {
-				isMatch = true
-				t = node
-				break
+		switch {
+		case seg == "**":
+			if len(rest) != len(seg) {
+				panic(fmt.Sprintf("路由 %s 中的 ** 只能出现在最后", path))
 			}
-		}
-		//如果子节点没有和相应的路径匹配，那么就创建节点，并且放入树中
-		if !isMatch {
-			isEnd := false
-			if index == len(strs)-1 {
-				isEnd = true
+			if n.catchAllChild == nil {
+				n.catchAllChild = &treeNode{path: seg, nType: catchAll}
 			}
+			n = n.catchAllChild
+		case seg == "*" || (seg != "" && seg[0] == ':'):
+			n = n.putParam(path, seg)
+		default:
+			//静态部分一直到下一个参数段为止，包含结尾的 /
+			end := staticEnd(rest)
+			n = n.putStatic(rest[:end])
+			rest = rest[end:]
+			continue
+		}
+		rest = rest[len(seg):]
+	}
+	n.isEnd = true
+	n.routerName = path
+	return n
+}
+
+// 添加参数子节点，seg 形如 :id、:id<int>、:name<[a-z]+\.txt> 或者 *
+func (t *treeNode) putParam(path string, seg string) *treeNode {
+	for _, child := range t.paramChildren {
+		if child.path == seg {
+			return child
+		}
+	}
+	key, constraint, err := parseParam(seg)
+	if err != nil {
+		panic(fmt.Sprintf("路由 %s 中的参数 %s 不正确: %v", path, seg, err))
+	}
+	child := &treeNode{path: seg, nType: param, key: key, constraint: constraint}
+	last := len(t.paramChildren) - 1
+	if last >= 0 && t.paramChildren[last].constraint == nil {
+		if constraint == nil {
+			panic(fmt.Sprintf("路由 %s 中的 %s 和已有的 %s 冲突", path, seg, t.paramChildren[last].path))
+		}
+		//不带约束的参数节点始终排在最后，作为兜底
+		t.paramChildren = append(t.paramChildren[:last], child, t.paramChildren[last])
+		return child
+	}
+	t.paramChildren = append(t.paramChildren, child)
+	return child
+}
 
-			node := &treeNode{name: name, children: make([]*treeNode, 0)}
-			children = append(children, node)
-			t.children = children
-			t.isEnd = isEnd
-			t = node
+// 找到静态部分的结尾，即下一个以 : 或 * 开头的路径段的开始位置
+func staticEnd(path string) int {
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && i+1 < len(path) && (path[i+1] == ':' || path[i+1] == '*') {
+			return i + 1
+		}
+	}
+	return len(path)
+}
+
+// 在静态子节点中插入一段路径，公共前缀不同时拆分节点，返回路径结尾的节点
+func (t *treeNode) putStatic(path string) *treeNode {
+	n := t
+	for {
+		i := strings.IndexByte(n.indices, path[0])
+		if i < 0 {
+			child := &treeNode{path: path, nType: static}
+			n.indices += string(path[0])
+			n.children = append(n.children, child)
+			return child
+		}
+		child := n.children[i]
+		l := commonPrefix(child.path, path)
+		if l < len(child.path) {
+			//拆分节点，原节点保留公共前缀，剩余部分成为它唯一的子节点
+			split := *child
+			split.path = child.path[l:]
+			*child = treeNode{
+				path:     child.path[:l],
+				nType:    static,
+				indices:  string(split.path[0]),
+				children: []*treeNode{&split},
+			}
+		}
+		if l == len(path) {
+			return child
 		}
+		path = path[l:]
+		n = child
+	}
+}
 
+func commonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
 	}
-	t = root
+	return i
 }
 
-// Get 获得路径树的叶子节点
-func (t *treeNode) Get(path string) *treeNode {
+// Param 路径参数，Key为路由中的参数名，Value为请求路径中对应的值
+type Param struct {
+	Key   string
+	Value string
+}
 
-	strs := strings.Split(path, "/")
-	routerName := ""
+// Params 按路由中出现的顺序保存的路径参数
+type Params []Param
 
-	for index, name := range strs {
-		if index == 0 {
-			continue
+// Get 根据参数名获得参数值
+func (ps Params) Get(name string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value, true
 		}
-		children := t.children
-		isMatch := false
-		for _, node := range children {
-			//判断所有子树路径是否和传入的路径相匹配，如果有对应匹配
-			//就说明树中对应存在，直接跳过循环
-			if node.name == name ||
-				node.name == "*" ||
-				strings.Contains(node.name, ":") {
+	}
+	return "", false
+}
 
-				isMatch = true
+// ByName 根据参数名获得参数值，不存在返回空串
+func (ps Params) ByName(name string) string {
+	value, _ := ps.Get(name)
+	return value
+}
 
-				routerName += "/" + node.name
-				t = node
-				node.routerName = routerName
-				if index == len(strs)-1 {
+// Get 获得路径树的叶子节点，同时返回匹配过程中捕获的路径参数
+// :id 捕获一段路径，参数名为id；* 捕获一段路径，参数名为*；** 捕获剩余的全部路径，参数名为**
+// :id<int> 只捕获满足约束的一段路径，不满足时继续尝试其他路由
+// 同一位置上按 静态 > 参数 > 通配 的优先级匹配，匹配失败时回溯
+func (t *treeNode) Get(path string) (*treeNode, Params) {
+	return t.GetFunc(path, nil)
+}
 
+// GetFunc 和 Get 相同，但是只返回accept为true的叶子节点，不满足时继续回溯尝试优先级更低的路由
+// 比如按请求方式匹配时，GET /user/profile 不会挡住 DELETE /user/:id，accept为nil时接受任意节点
+func (t *treeNode) GetFunc(path string, accept func(*treeNode) bool) (*treeNode, Params) {
+	var params Params
+	node := t.match(path, &params, accept)
+	if node == nil {
+		return nil, nil
+	}
+	return node, params
+}
+
+// 在当前节点之后匹配剩余的路径
+func (t *treeNode) match(path string, params *Params, accept func(*treeNode) bool) *treeNode {
+	if path == "" {
+		if t.isEnd && (accept == nil || accept(t)) {
+			return t
+		}
+	} else if i := strings.IndexByte(t.indices, path[0]); i >= 0 {
+		child := t.children[i]
+		if strings.HasPrefix(path, child.path) {
+			if node := child.match(path[len(child.path):], params, accept); node != nil {
+				return node
+			}
+		}
+	}
+	if len(t.paramChildren) > 0 {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			for _, child := range t.paramChildren {
+				if !child.constraint.match(path[:end]) {
+					continue
+				}
+				*params = append(*params, Param{Key: child.key, Value: path[:end]})
+				if node := child.match(path[end:], params, accept); node != nil {
 					return node
 				}
-				break
+				*params = (*params)[:len(*params)-1]
 			}
 		}
-		if !isMatch {
-			for _, node := range children {
+	}
+	if t.catchAllChild != nil && (accept == nil || accept(t.catchAllChild)) {
+		*params = append(*params, Param{Key: "**", Value: path})
+		return t.catchAllChild
+	}
+	return nil
+}
 
-				// /user/** 遇到**都能匹配
-				if node.name == "**" {
-					routerName += "/" + node.name
-					node.routerName = routerName
+// 忽略大小写匹配路径，返回按注册时的大小写拼出的路径，参数部分保持请求中的原样
+// 和 GetFunc 一样只接受accept为true的叶子节点，accept为nil时接受任意节点
+func (t *treeNode) findCaseInsensitive(path string, accept func(*treeNode) bool) (string, bool) {
+	buf, ok := t.matchCaseInsensitive(path, make([]byte, 0, len(path)), accept)
+	return string(buf), ok
+}
 
-					return node
+func (t *treeNode) matchCaseInsensitive(path string, buf []byte, accept func(*treeNode) bool) ([]byte, bool) {
+	if path == "" {
+		if t.isEnd && (accept == nil || accept(t)) {
+			return buf, true
+		}
+	} else {
+		//首字节的大小写可能不同，所以检查所有的静态子节点
+		for _, child := range t.children {
+			if len(path) >= len(child.path) && strings.EqualFold(path[:len(child.path)], child.path) {
+				if out, ok := child.matchCaseInsensitive(path[len(child.path):], append(buf, child.path...), accept); ok {
+					return out, true
 				}
 			}
 		}
 	}
-	return nil
+	if len(t.paramChildren) > 0 {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			for _, child := range t.paramChildren {
+				if !child.constraint.match(path[:end]) {
+					continue
+				}
+				if out, ok := child.matchCaseInsensitive(path[end:], append(buf, path[:end]...), accept); ok {
+					return out, true
+				}
+			}
+		}
+	}
+	if t.catchAllChild != nil && (accept == nil || accept(t.catchAllChild)) {
+		return append(buf, path...), true
+	}
+	return nil, false
 }
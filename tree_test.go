@@ -2,23 +2,260 @@ package csgo
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
 func TestTreeNode(t *testing.T) {
 
-	root := &treeNode{name: "/", children: make([]*treeNode, 0)}
+	root := &treeNode{}
 	root.Put("/user/get/:id")
 	root.Put("/user/create/user")
 	root.Put("/user/create/userT")
 	root.Put("/order/get/sss")
 
-	n := root.Get("/user/get/1")
+	n, _ := root.Get("/user/get/1")
 	fmt.Println(n)
-	n = root.Get("/user/create/user")
+	n, _ = root.Get("/user/create/user")
 	fmt.Println(n)
-	n = root.Get("/user/create/userT")
+	n, _ = root.Get("/user/create/userT")
 	fmt.Println(n)
-	n = root.Get("/order/get/sss")
+	n, _ = root.Get("/order/get/sss")
 	fmt.Println(n)
 }
+
+func TestTreeNodeParams(t *testing.T) {
+	root := &treeNode{}
+	root.Put("/user/get/:id")
+	root.Put("/file/*/info")
+	root.Put("/static/**")
+
+	_, params := root.Get("/user/get/10")
+	if params.ByName("id") != "10" {
+		t.Errorf("id = %q, want 10", params.ByName("id"))
+	}
+	_, params = root.Get("/file/a.txt/info")
+	if params.ByName("*") != "a.txt" {
+		t.Errorf("* = %q, want a.txt", params.ByName("*"))
+	}
+	_, params = root.Get("/static/css/app.css")
+	if params.ByName("**") != "css/app.css" {
+		t.Errorf("** = %q, want css/app.css", params.ByName("**"))
+	}
+}
+
+func TestTreeNodePriority(t *testing.T) {
+	root := &treeNode{}
+	//注册顺序不影响匹配结果
+	root.Put("/user/:id")
+	root.Put("/user/**")
+	root.Put("/user/profile")
+	root.Put("/user/:id/posts")
+	root.Put("/user/profile/settings")
+	root.Put("/users")
+
+	tests := []struct {
+		path  string
+		route string
+		param string
+	}{
+		{"/user/profile", "/user/profile", ""},
+		{"/user/12", "/user/:id", "12"},
+		{"/user/profile/posts", "/user/:id/posts", "profile"},
+		{"/user/profile/settings", "/user/profile/settings", ""},
+		{"/user/12/other", "/user/**", ""},
+		{"/users", "/users", ""},
+	}
+	for _, test := range tests {
+		n, params := root.Get(test.path)
+		if n == nil {
+			t.Errorf("%s: no route matched", test.path)
+			continue
+		}
+		if n.routerName != test.route {
+			t.Errorf("%s: matched %s, want %s", test.path, n.routerName, test.route)
+		}
+		if test.param != "" && params.ByName("id") != test.param {
+			t.Errorf("%s: id = %q, want %q", test.path, params.ByName("id"), test.param)
+		}
+	}
+	if n, _ := root.Get("/order"); n != nil {
+		t.Errorf("/order: matched %s, want nothing", n.routerName)
+	}
+	//静态路由不接受时回溯到参数路由和通配路由
+	n, params := root.GetFunc("/user/profile", func(n *treeNode) bool { return n.routerName != "/user/profile" })
+	if n == nil || n.routerName != "/user/:id" || params.ByName("id") != "profile" {
+		t.Errorf("GetFunc: got %v %v", n, params)
+	}
+	n, _ = root.GetFunc("/user/profile", func(n *treeNode) bool { return n.routerName == "/user/**" })
+	if n == nil || n.routerName != "/user/**" {
+		t.Errorf("GetFunc: got %v", n)
+	}
+
+	//不同请求方式的路由不会相互遮挡
+	engine := New()
+	group := engine.Group("user")
+	group.Get("/profile", func(ctx *Context) { ctx.String(http.StatusOK, "profile") })
+	group.Delete("/:id", func(ctx *Context) { ctx.String(http.StatusOK, "delete "+ctx.Param("id")) })
+	requests := []struct {
+		method string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, http.StatusOK, "profile"},
+		{http.MethodDelete, http.StatusOK, "delete profile"},
+		{http.MethodPost, http.StatusMethodNotAllowed, ""},
+	}
+	for _, r := range requests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(r.method, "/user/profile", nil))
+		if w.Code != r.code || (r.body != "" && w.Body.String() != r.body) {
+			t.Errorf("%s /user/profile: got %d %q", r.method, w.Code, w.Body.String())
+		}
+		//Allow中包含所有能匹配的路由支持的请求方式
+		if r.code == http.StatusMethodNotAllowed {
+			if allow := w.Header().Get("Allow"); allow != "DELETE, GET, HEAD, OPTIONS" {
+				t.Errorf("Allow = %q", allow)
+			}
+		}
+	}
+}
+
+func TestTreeNodeConflict(t *testing.T) {
+	tests := [][]string{
+		{"/user/:id", "/user/:name"},
+		{"/user/:id", "/user/*"},
+		{"/user/**/info"},
+		{"user"},
+	}
+	for _, routes := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v: want panic", routes)
+				}
+			}()
+			root := &treeNode{}
+			for _, route := range routes {
+				root.Put(route)
+			}
+		}()
+	}
+}
+
+func TestGroupRouteConflict(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic")
+		}
+	}()
+	engine := New()
+	engine.Group("user").Get("/get", func(ctx *Context) {})
+	engine.Group("").Post("/user/get", func(ctx *Context) {})
+}
+
+// 100个组，每个组20条路由
+func newBenchmarkEngine() *Engine {
+	engine := New()
+	handle := func(ctx *Context) {}
+	for i := 0; i < 100; i++ {
+		group := engine.Group(fmt.Sprintf("group%d", i))
+		for j := 0; j < 10; j++ {
+			group.Get(fmt.Sprintf("/static%d/list", j), handle)
+			group.Get(fmt.Sprintf("/param%d/:id/info", j), handle)
+		}
+	}
+	return engine
+}
+
+func BenchmarkTreeNodeGet(b *testing.B) {
+	engine := newBenchmarkEngine()
+	paths := []string{"/group0/static0/list", "/group50/param5/12/info", "/group99/static9/list", "/group99/param9/abc/info"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if n, _ := engine.treeNode.Get(paths[i%len(paths)]); n == nil {
+			b.Fatal("no route matched")
+		}
+	}
+}
+
+func BenchmarkEngineServeHTTP(b *testing.B) {
+	engine := newBenchmarkEngine()
+	r := httptest.NewRequest(http.MethodGet, "/group99/param9/abc/info", nil)
+	w := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		engine.ServeHTTP(w, r)
+	}
+}
+
+func TestTreeNodeConstraint(t *testing.T) {
+	root := &treeNode{}
+	root.Put("/user/:name")
+	root.Put("/user/:id<int>")
+	root.Put(`/file/:name<[a-z]+\.txt>`)
+	root.Put("/order/:id<uuid>")
+
+	tests := []struct {
+		path  string
+		route string
+	}{
+		{"/user/12", "/user/:id<int>"},
+		{"/user/tom", "/user/:name"},
+		{"/file/a.txt", `/file/:name<[a-z]+\.txt>`},
+		{"/file/A.txt", ""},
+		{"/file/a.txt.bak", ""},
+		{"/order/0b4e7c1a-3f5d-4c2e-9a8b-1c2d3e4f5a6b", "/order/:id<uuid>"},
+		{"/order/12", ""},
+	}
+	for _, test := range tests {
+		n, params := root.Get(test.path)
+		if test.route == "" {
+			if n != nil {
+				t.Errorf("%s: matched %s, want nothing", test.path, n.routerName)
+			}
+			continue
+		}
+		if n == nil || n.routerName != test.route {
+			t.Errorf("%s: matched %v, want %s", test.path, n, test.route)
+			continue
+		}
+		if len(params) != 1 || params[0].Key == "" || params[0].Key[len(params[0].Key)-1] == '>' {
+			t.Errorf("%s: params = %v", test.path, params)
+		}
+	}
+
+	for _, route := range []string{"/user/:id<int", "/user/:id<>", "/user/:<int>", "/user/:id<[a-z>"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: want panic", route)
+				}
+			}()
+			(&treeNode{}).Put(route)
+		}()
+	}
+}
+
+func BenchmarkEngineMiddleware(b *testing.B) {
+	engine := New()
+	noop := func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			next(ctx)
+		}
+	}
+	engine.Use(noop, noop)
+	group := engine.Group("api").Group("v1")
+	group.Use(noop, noop)
+	group.Get("/user/:id", func(ctx *Context) {}, noop)
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/user/12", nil)
+	w := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		engine.ServeHTTP(w, r)
+	}
+}
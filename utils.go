@@ -1,6 +1,7 @@
 package csgo
 
 import (
+	"path"
 	"strings"
 	"unicode"
 )
@@ -16,6 +17,35 @@ func SubStringLast(str string, substr string) string {
 	return str[index+len(substr):]
 }
 
+// 将组名拼接到前缀之后，结果以 / 开头且不以 / 结尾，比如 joinPaths("/api", "v1/") 为 /api/v1
+func joinPaths(prefix string, name string) string {
+	name = strings.Trim(name, "/")
+	if name == "" {
+		return prefix
+	}
+	return prefix + "/" + name
+}
+
+// 清理路径中多余的 /、. 和 ..，保留结尾的 /
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	cleaned := path.Clean("/" + p)
+	if p[len(p)-1] == '/' && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// 去掉或者加上结尾的 /
+func toggleTrailingSlash(p string) string {
+	if strings.HasSuffix(p, "/") {
+		return p[:len(p)-1]
+	}
+	return p + "/"
+}
+
 func isASCII(s string) bool {
 	for i := 0; i < len(s); i++ {
 		if s[i] > unicode.MaxASCII {